@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+/*
+ARTICLE TYPES
+
+Not every item of an issue is a research article: book reviews, obituaries,
+corrigenda and editorials have no abstract or keywords and often no
+references. The type of an item is taken from, in order:
+
+1. a marker line "@type: obituary" in the item's header, added by the editor;
+   names are those of ArticleType.String() (spaces or dashes also accepted)
+2. a rubric heading above the citation line: "Short communication",
+   "Book review", "Obituary", "Corrigendum", "Editorial" and their Russian forms
+3. the title: "Corrigendum to ...", "Erratum to ...", "In memory of ...", "Памяти ..."
+4. otherwise a research article
+
+Marker and rubric lines are removed from the header before it is parsed.
+Required fields depend on the type (articleTypeRequirements); a missing one is
+reported, anything else the type does not need is left empty silently.
+*/
+
+// ArticleType is the kind of an item in an issue
+type ArticleType int
+
+const (
+	ArticleResearch ArticleType = iota
+	ArticleShortCommunication
+	ArticleReview
+	ArticleObituary
+	ArticleErratum
+	ArticleEditorial
+)
+
+func (t ArticleType) String() string {
+	switch t {
+	case ArticleShortCommunication:
+		return "short_communication"
+	case ArticleReview:
+		return "review"
+	case ArticleObituary:
+		return "obituary"
+	case ArticleErratum:
+		return "erratum"
+	case ArticleEditorial:
+		return "editorial"
+	default:
+		return "research_article"
+	}
+}
+
+// articleRequirements lists the fields an item of a type must have
+type articleRequirements struct {
+	abstract   bool
+	keywords   bool
+	references bool
+}
+
+var articleTypeRequirements = map[ArticleType]articleRequirements{
+	ArticleResearch:           {abstract: true, keywords: true, references: true},
+	ArticleShortCommunication: {abstract: true, references: true},
+	ArticleReview:             {references: true}, // book reviews have no abstract
+	ArticleObituary:           {},
+	ArticleErratum:            {},
+	ArticleEditorial:          {},
+}
+
+var (
+	articleTypeMarkerRegex = regexp.MustCompile(`(?i)^\s*@type\s*:\s*(.+?)\s*$`)
+	// rubric headings: the whole line is the heading
+	articleTypeHeadings = []struct {
+		articleType ArticleType
+		re          *regexp.Regexp
+	}{
+		{ArticleShortCommunication, regexp.MustCompile(`(?i)^(?:short\s+communications?|краткое\s+сообщение|краткие\s+сообщения)\.?$`)},
+		{ArticleReview, regexp.MustCompile(`(?i)^(?:review|book\s+review|reviews?\s+article|обзор|рецензия|рецензии)\.?$`)},
+		{ArticleObituary, regexp.MustCompile(`(?i)^(?:obituary|in\s+memoriam|некролог|памяти\s+коллеги)\.?$`)},
+		{ArticleErratum, regexp.MustCompile(`(?i)^(?:erratum|errata|corrigendum|corrigenda|correction|исправление|поправка)\.?$`)},
+		{ArticleEditorial, regexp.MustCompile(`(?i)^(?:editorial|from\s+the\s+editors?|от\s+редакции|редакционная\s+статья)\.?$`)},
+	}
+	// titles that give the type away
+	articleTypeTitles = []struct {
+		articleType ArticleType
+		re          *regexp.Regexp
+	}{
+		{ArticleErratum, regexp.MustCompile(`(?i)^(?:corrigendum|erratum|correction)\s+(?:to|of)\s`)},
+		{ArticleErratum, regexp.MustCompile(`(?i)^(?:исправление|поправка)\s+к\s`)},
+		{ArticleObituary, regexp.MustCompile(`(?i)^(?:in\s+memory\s+of|in\s+memoriam)[\s:]`)},
+		{ArticleObituary, regexp.MustCompile(`(?i)^памяти\s`)},
+	}
+)
+
+// parseArticleTypeName accepts "short_communication", "short communication" or "short-communication"
+func parseArticleTypeName(name string) (ArticleType, bool) {
+	name = strings.ToLower(strings.NewReplacer(" ", "_", "-", "_").Replace(strings.TrimSpace(name)))
+	for t := ArticleResearch; t <= ArticleEditorial; t++ {
+		if t.String() == name {
+			return t, true
+		}
+	}
+	if name == "research" || name == "article" {
+		return ArticleResearch, true
+	}
+	return ArticleResearch, false
+}
+
+// classifyArticle finds the item's type from a marker or rubric heading above the
+// citation line and removes those lines from the header. source says what decided
+// the type ("" when nothing did); problems are reported as warnings.
+func classifyArticle(art string) (t ArticleType, header, source string, problems []string) {
+	lines := strings.Split(art, "\n")
+	kept := make([]string, 0, len(lines))
+	fromMarker := false
+	inHeader := true
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if inHeader && trimmed != "" {
+			if _, ok := parseCitationLine(trimmed); ok {
+				inHeader = false
+			}
+		}
+
+		// The marker may be anywhere in the header; it wins over headings
+		if m := articleTypeMarkerRegex.FindStringSubmatch(line); m != nil {
+			if mt, ok := parseArticleTypeName(m[1]); ok {
+				t, source, fromMarker = mt, "marker", true
+			} else {
+				problems = append(problems, fmt.Sprintf("Unknown article type %q in @type marker", m[1]))
+			}
+			continue
+		}
+
+		if inHeader {
+			if ht, ok := articleTypeHeading(trimmed); ok {
+				if !fromMarker && source == "" {
+					t, source = ht, fmt.Sprintf("heading %q", trimmed)
+				}
+				continue
+			}
+		}
+		kept = append(kept, line)
+	}
+	return t, strings.Join(kept, "\n"), source, problems
+}
+
+func articleTypeHeading(line string) (ArticleType, bool) {
+	for _, h := range articleTypeHeadings {
+		if h.re.MatchString(line) {
+			return h.articleType, true
+		}
+	}
+	return ArticleResearch, false
+}
+
+// articleTypeFromTitle recognises corrigenda and obituaries by their titles
+func articleTypeFromTitle(title string) (ArticleType, bool) {
+	title = strings.TrimSpace(title)
+	for _, h := range articleTypeTitles {
+		if h.re.MatchString(title) {
+			return h.articleType, true
+		}
+	}
+	return ArticleResearch, false
+}
+
+// checkArticleRequirements reports the fields the item's type requires but the item lacks
+func checkArticleRequirements(clog *ConversionLog, artNum int, art Article) {
+	req := articleTypeRequirements[art.articleType]
+	if req.abstract && art.abstractEn == "" && art.abstractRu == "" {
+		clog.printError(artNum, "ABSTRACT section not found in article text")
+	}
+	if req.keywords && len(art.keywordsEn) == 0 && len(art.keywordsRu) == 0 {
+		clog.printWarning(artNum, "KEYWORDS", "Keywords section not found, continuing with empty keywords")
+	}
+	if req.references && len(art.references) == 0 {
+		clog.printWarning(artNum, "REFERENCES", fmt.Sprintf("No references, but a %s should have them", strings.ReplaceAll(art.articleType.String(), "_", " ")))
+	}
+}
@@ -0,0 +1,445 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+/*
+CITATION INDEX
+
+`citations` reads the References sheets of converted issues and counts which
+works our journals cite:
+
+	server citations [-o citations.xlsx] [-top 50] <workbook.xlsx | directory>...
+
+Sheets are read by header (ref.full, ref.authors, ref.year, ref.title,
+ref.meta, ref.doi, ref.art_doi), so workbooks from older versions without the
+newer columns still load. An article that appears in several workbooks (an
+issue converted twice) is counted once, from the first workbook.
+
+The same work cited in different articles is recognised by a key built from
+the first author's surname, the year without its letter ("2003a") and the first
+citationKeyTitleWords significant words of the title: punctuation, case,
+translation brackets and stop words do not matter.
+
+Self-citations are references to our own journals: a 10.15298 DOI of a registry
+journal, or the journal's name or an alias in the reference (see citedJournal).
+
+The export has three sheets: most-cited works, self-citations and per-issue counts.
+*/
+
+const (
+	citationKeyTitleWords = 5
+	citationIndexTop      = 50
+)
+
+var citationYearRegex = regexp.MustCompile(`(?:19|20)\d{2}`)
+
+// CitedWork is one work with all the references to it
+type CitedWork struct {
+	Key      string
+	Authors  string // as written in the first reference seen
+	Year     string
+	Title    string
+	Journal  string   // code of our journal when it is a self-citation
+	CitingBy []string // DOIs of the citing articles, in load order
+}
+
+// CitationRecord is one row of a References sheet
+type CitationRecord struct {
+	Source  string // workbook the row was read from
+	Row     int
+	Full    string
+	Authors string
+	Year    string
+	Title   string
+	Meta    string
+	DOI     string
+	ArtDOI  string
+	Key     string
+	Journal string // code of our journal when it is a self-citation
+	// CitingYear is the publication year of the citing issue, "" if unknown
+	CitingYear string
+}
+
+// IssueCitations are the counts of one citing issue
+type IssueCitations struct {
+	Journal        string
+	Volume, Issue  int
+	Year           string // publication year, "" if unknown
+	Articles       map[string]bool
+	References     int
+	SelfCitations  int
+	SelfByJournal  map[string]int
+	unknownArticle bool // art_doi is not one of our DOIs
+}
+
+// CitationIndex is built from the References sheets of converted issues
+type CitationIndex struct {
+	Records   []CitationRecord
+	Works     map[string]*CitedWork
+	Issues    map[string]*IssueCitations // by "journal volume issue"
+	Workbooks int                        // workbooks loaded, without the skipped ones
+	seen      map[string]string          // art_doi -> workbook it was loaded from
+}
+
+func newCitationIndex() *CitationIndex {
+	return &CitationIndex{
+		Works:  map[string]*CitedWork{},
+		Issues: map[string]*IssueCitations{},
+		seen:   map[string]string{},
+	}
+}
+
+// citationKey is "surname|year|first title words"; "" if the reference has no author and no title
+func citationKey(authors, year, title string) string {
+	surname := ""
+	if list, _ := splitReferenceAuthors(authors); len(list) > 0 {
+		surname = strings.Join(matchTokens(list[0].Surname), "")
+	}
+	words := matchTokens(cleanRefTitle(title))
+	if len(words) > citationKeyTitleWords {
+		words = words[:citationKeyTitleWords]
+	}
+	if surname == "" && len(words) == 0 {
+		return ""
+	}
+	return surname + "|" + citationYearRegex.FindString(year) + "|" + strings.Join(words, " ")
+}
+
+// LoadWorkbook adds the References sheet of a converted issue; warnings go to clog.
+// Other workbooks in the scanned directories (a previous citations.xlsx) are skipped.
+func (idx *CitationIndex) LoadWorkbook(path string, clog *ConversionLog) error {
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if i, _ := f.GetSheetIndex("References"); i < 0 {
+		clog.Printf("⚠️  %s has no References sheet, skipped\n", path)
+		return nil
+	}
+
+	rows, err := f.GetRows("References")
+	if err != nil {
+		return fmt.Errorf("failed to read References sheet of %s: %w", path, err)
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("References sheet of %s is empty", path)
+	}
+
+	col := map[string]int{}
+	for i, h := range rows[0] {
+		col[strings.TrimSpace(h)] = i
+	}
+	for _, required := range []string{"ref.full", "ref.art_doi"} {
+		if _, ok := col[required]; !ok {
+			return fmt.Errorf("References sheet of %s has no %s column", path, required)
+		}
+	}
+	cell := func(row []string, name string) string {
+		if i, ok := col[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	years, err := articleYears(f)
+	if err != nil {
+		return fmt.Errorf("failed to read articles sheet of %s: %w", path, err)
+	}
+
+	skipped := map[string]bool{}
+	for r, row := range rows[1:] {
+		rec := CitationRecord{
+			Source:  path,
+			Row:     r + 2,
+			Full:    cell(row, "ref.full"),
+			Authors: cell(row, "ref.authors"),
+			Year:    cell(row, "ref.year"),
+			Title:   cell(row, "ref.title"),
+			Meta:    cell(row, "ref.meta"),
+			DOI:     cell(row, "ref.doi"),
+			ArtDOI:  normalizeDOI(cell(row, "ref.art_doi")),
+		}
+		if rec.Full == "" {
+			continue
+		}
+		// The same issue converted twice; rows without an article DOI cannot be told apart
+		if rec.ArtDOI != "" {
+			if first, ok := idx.seen[rec.ArtDOI]; ok && first != path {
+				if !skipped[rec.ArtDOI] {
+					clog.Printf("⚠️  %s: article %s already loaded from %s, skipped\n", path, rec.ArtDOI, first)
+					skipped[rec.ArtDOI] = true
+				}
+				continue
+			}
+			idx.seen[rec.ArtDOI] = path
+		}
+
+		// Workbooks from before the References columns were added: parse the reference again
+		if _, ok := col["ref.title"]; !ok {
+			pr := parseReference(rec.Full)
+			rec.Authors, rec.Year, rec.Title, rec.Meta, rec.DOI = pr.Authors, pr.Year, pr.Title, pr.Meta, pr.DOI
+		}
+		rec.Key = citationKey(rec.Authors, rec.Year, rec.Title)
+		if j, ok := referenceJournal(rec.Full, rec.Title, rec.Meta, rec.DOI); ok {
+			rec.Journal = j.Code
+		}
+		rec.CitingYear = years[rec.ArtDOI]
+		idx.add(rec)
+	}
+	idx.Workbooks++
+	return nil
+}
+
+func (idx *CitationIndex) add(rec CitationRecord) {
+	idx.Records = append(idx.Records, rec)
+
+	if rec.Key != "" {
+		w := idx.Works[rec.Key]
+		if w == nil {
+			w = &CitedWork{Key: rec.Key, Authors: rec.Authors, Year: rec.Year, Title: rec.Title}
+			idx.Works[rec.Key] = w
+		}
+		if w.Journal == "" {
+			w.Journal = rec.Journal
+		}
+		if !slices.Contains(w.CitingBy, rec.ArtDOI) {
+			w.CitingBy = append(w.CitingBy, rec.ArtDOI)
+		}
+	}
+
+	issueKey := issueKeyOf(rec.ArtDOI)
+	parsed, err := parseJournalDOI(rec.ArtDOI)
+	is := idx.Issues[issueKey]
+	if is == nil {
+		is = &IssueCitations{Articles: map[string]bool{}, SelfByJournal: map[string]int{}, unknownArticle: err != nil}
+		if err == nil {
+			is.Journal, is.Volume, is.Issue = parsed.Journal.Code, parsed.Volume, parsed.Issue
+		}
+		idx.Issues[issueKey] = is
+	}
+	if is.Year == "" {
+		is.Year = rec.CitingYear
+	}
+	is.Articles[rec.ArtDOI] = true
+	is.References++
+	if rec.Journal != "" {
+		is.SelfCitations++
+		is.SelfByJournal[rec.Journal]++
+	}
+}
+
+// referenceJournal finds the journal of ours a reference cites. The title is searched
+// too: without "//" the journal name often stays in the title.
+func referenceJournal(full, title, meta, doi string) (JournalEntry, bool) {
+	if doi == "" {
+		doi = normalizeDOI(full)
+	}
+	return citedJournal(meta+" "+title, doi)
+}
+
+// articleYears maps article DOIs to the year of the issue's pubdate ("20.06.2025") on the
+// articles sheet; workbooks without the sheet or columns give an empty map
+func articleYears(f *excelize.File) (map[string]string, error) {
+	years := map[string]string{}
+	if idx, _ := f.GetSheetIndex("articles"); idx < 0 {
+		return years, nil
+	}
+	rows, err := f.GetRows("articles")
+	if err != nil || len(rows) == 0 {
+		return years, err
+	}
+	doiCol, dateCol := -1, -1
+	for i, h := range rows[0] {
+		switch strings.TrimSpace(h) {
+		case "articles.DOI":
+			doiCol = i
+		case "pubdate":
+			dateCol = i
+		}
+	}
+	if doiCol < 0 || dateCol < 0 {
+		return years, nil
+	}
+	for _, row := range rows[1:] {
+		if doiCol < len(row) && dateCol < len(row) {
+			years[normalizeDOI(row[doiCol])] = citationYearRegex.FindString(row[dateCol])
+		}
+	}
+	return years, nil
+}
+
+// MostCited returns the works cited by at least two articles, most cited first
+func (idx *CitationIndex) MostCited(limit int) []*CitedWork {
+	var works []*CitedWork
+	for _, w := range idx.Works {
+		if len(w.CitingBy) > 1 {
+			works = append(works, w)
+		}
+	}
+	sort.Slice(works, func(i, j int) bool {
+		if len(works[i].CitingBy) != len(works[j].CitingBy) {
+			return len(works[i].CitingBy) > len(works[j].CitingBy)
+		}
+		return works[i].Key < works[j].Key
+	})
+	if limit > 0 && len(works) > limit {
+		works = works[:limit]
+	}
+	return works
+}
+
+// SortedIssues returns the issues by journal, volume and issue; articles with foreign DOIs last
+func (idx *CitationIndex) SortedIssues() []*IssueCitations {
+	issues := make([]*IssueCitations, 0, len(idx.Issues))
+	for _, is := range idx.Issues {
+		issues = append(issues, is)
+	}
+	sort.Slice(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		if a.unknownArticle != b.unknownArticle {
+			return b.unknownArticle
+		}
+		if a.Journal != b.Journal {
+			return a.Journal < b.Journal
+		}
+		if a.Volume != b.Volume {
+			return a.Volume < b.Volume
+		}
+		return a.Issue < b.Issue
+	})
+	return issues
+}
+
+// Export writes the most-cited, self-citations and per-issue sheets
+func (idx *CitationIndex) Export(path string, top int) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	f.SetSheetName("Sheet1", "most-cited")
+	setRow(f, "most-cited", 1, "rank", "cited.key", "cited.authors", "cited.year", "cited.title", "cited.journal", "cited.count", "cited.citing_dois")
+	for i, w := range idx.MostCited(top) {
+		setRow(f, "most-cited", i+2, i+1, w.Key, w.Authors, w.Year, w.Title, w.Journal, len(w.CitingBy), strings.Join(w.CitingBy, "; "))
+	}
+
+	f.NewSheet("self-citations")
+	setRow(f, "self-citations", 1, "self.art_doi", "self.journal", "self.ref_full", "self.source", "self.source_row")
+	row := 2
+	for _, rec := range idx.Records {
+		if rec.Journal == "" {
+			continue
+		}
+		setRow(f, "self-citations", row, rec.ArtDOI, rec.Journal, rec.Full, filepath.Base(rec.Source), rec.Row)
+		row++
+	}
+
+	f.NewSheet("per-issue")
+	header := []any{"issue.journal", "issue.volume", "issue.issue", "issue.articles", "issue.references", "issue.self_citations"}
+	for _, j := range journalRegistry {
+		header = append(header, "issue.cites_"+strings.ToLower(j.Code))
+	}
+	setRow(f, "per-issue", 1, header...)
+	for i, is := range idx.SortedIssues() {
+		values := []any{is.Journal, is.Volume, is.Issue, len(is.Articles), is.References, is.SelfCitations}
+		if is.unknownArticle {
+			values[0], values[1], values[2] = "(other DOIs)", "", ""
+		}
+		for _, j := range journalRegistry {
+			values = append(values, is.SelfByJournal[j.Code])
+		}
+		setRow(f, "per-issue", i+2, values...)
+	}
+
+	if err := f.SaveAs(path); err != nil {
+		return fmt.Errorf("failed to save %s: %w", path, err)
+	}
+	return nil
+}
+
+// setRow writes values to consecutive cells of a row starting at column A
+func setRow(f *excelize.File, sheet string, row int, values ...any) {
+	for i, v := range values {
+		cell, _ := excelize.CoordinatesToCellName(i+1, row)
+		f.SetCellValue(sheet, cell, v)
+	}
+}
+
+// workbookPaths expands directories to the .xlsx files in them
+func workbookPaths(args []string) ([]string, error) {
+	var paths []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			paths = append(paths, arg)
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(arg, "*.xlsx"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(matches)
+		paths = append(paths, matches...)
+	}
+	return paths, nil
+}
+
+// runCitations handles `server citations`; returns the exit code
+func runCitations(args []string) int {
+	fs := flag.NewFlagSet("citations", flag.ContinueOnError)
+	out := fs.String("o", "citations.xlsx", "output workbook")
+	top := fs.Int("top", citationIndexTop, "number of most-cited works to list")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fmt.Println("Usage: citations [-o citations.xlsx] [-top 50] <workbook.xlsx | directory>...")
+		return 2
+	}
+
+	paths, err := workbookPaths(fs.Args())
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+	idx := newCitationIndex()
+	for _, path := range paths {
+		if err := idx.LoadWorkbook(path, nil); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return 1
+		}
+	}
+
+	self := 0
+	for _, rec := range idx.Records {
+		if rec.Journal != "" {
+			self++
+		}
+	}
+	fmt.Printf("📚 %d references from %d workbooks, %d distinct works, %d self-citations\n",
+		len(idx.Records), idx.Workbooks, len(idx.Works), self)
+	for i, w := range idx.MostCited(10) {
+		fmt.Printf("   %2d. %s %s. %s (%d)\n", i+1, w.Authors, w.Year, w.Title, len(w.CitingBy))
+	}
+
+	if err := idx.Export(*out, *top); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+	fmt.Printf("✓ Citation index saved: %s\n", *out)
+	return 0
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// writeReferencesWorkbook saves a workbook with a References sheet of (art_doi, reference) rows
+func writeReferencesWorkbook(t *testing.T, path string, rows ...[2]string) {
+	t.Helper()
+	f := excelize.NewFile()
+	defer f.Close()
+	f.SetSheetName("Sheet1", "References")
+	setRow(f, "References", 1, "ref.art_doi", "ref.full")
+	for i, r := range rows {
+		setRow(f, "References", i+2, r[0], r[1])
+	}
+	if err := f.SaveAs(path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCitationIndexLoadWorkbooks(t *testing.T) {
+	dir := t.TempDir()
+	const (
+		refA = "Hieke F. 1995. Revision of the subgenus Curtonotus // Deutsche Entomologische Zeitschrift. Vol.42. No.1. P.1–76."
+		refB = "Ivanova A.B. 2023. Ground beetles of the Kulunda steppe // Euroasian Entomological Journal. Vol.22. No.4. P.201–208."
+	)
+	issue := filepath.Join(dir, "EEJ_24_3.xlsx")
+	writeReferencesWorkbook(t, issue,
+		[2]string{"10.15298/euroasentj.24.03.01", refA},
+		[2]string{"10.15298/euroasentj.24.03.02", refB},
+		[2]string{"", refA},
+	)
+	// the same issue converted again, with one more row without an article DOI
+	again := filepath.Join(dir, "EEJ_24_3_again.xlsx")
+	writeReferencesWorkbook(t, again,
+		[2]string{"10.15298/euroasentj.24.03.01", refA},
+		[2]string{"", refB},
+	)
+
+	// a previous run's output in the same directory
+	idx := newCitationIndex()
+	if err := idx.LoadWorkbook(issue, nil); err != nil {
+		t.Fatal(err)
+	}
+	previous := filepath.Join(dir, "citations.xlsx")
+	if err := idx.Export(previous, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	idx = newCitationIndex()
+	paths, err := workbookPaths([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 3 {
+		t.Fatalf("workbooks %v, want 3", paths)
+	}
+	for _, path := range paths {
+		if err := idx.LoadWorkbook(path, nil); err != nil {
+			t.Fatalf("LoadWorkbook(%s): %v", filepath.Base(path), err)
+		}
+	}
+
+	if idx.Workbooks != 2 {
+		t.Errorf("%d workbooks loaded, want 2 without citations.xlsx", idx.Workbooks)
+	}
+	// 3 rows of the issue, the repeated article skipped, the row without art_doi kept
+	if len(idx.Records) != 4 {
+		t.Fatalf("%d records, want 4", len(idx.Records))
+	}
+	var noDOI int
+	for _, rec := range idx.Records {
+		if rec.ArtDOI == "" {
+			noDOI++
+		}
+		if rec.ArtDOI == "10.15298/euroasentj.24.03.01" && rec.Source != issue {
+			t.Errorf("article 01 loaded again from %s", filepath.Base(rec.Source))
+		}
+	}
+	if noDOI != 2 {
+		t.Errorf("%d rows without art_doi, want both kept", noDOI)
+	}
+}
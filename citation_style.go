@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+/*
+CITATION STYLES
+
+Reference lists are parsed by a CitationStyle profile:
+
+	kmk   Authors Year. Title // Journal. Vol.X. No.Y. P.XXX-XXX.   (our journals, default)
+	apa   Authors (Year). Title. Journal, X(Y), XXX-XXX.
+	gost  Authors Title / Authors // Journal. – Year. – Т. X, № Y. – С. XXX-XXX.   (GOST 7.1)
+
+Which profile is used for an article:
+1. CITATION_STYLE environment variable, if set (one of the names above or "auto")
+2. citation_style in the journal's state file (state/<CODE>_state.yaml), if set
+3. CitationStyle of the journal in the registry, if set
+4. the default profile (kmk)
+"auto" picks the profile that recognizes most of the article's references,
+falling back to the default when none recognizes at least half of them.
+*/
+
+const (
+	defaultCitationStyle    = "kmk"
+	autoCitationStyle       = "auto"
+	citationStyleMinMatches = 0.5 // share of references a profile must recognize in auto mode
+)
+
+// CitationStyle parses references written in one citation style
+type CitationStyle interface {
+	Name() string
+	// Parse parses one reference
+	Parse(ref string) ParsedReference
+	// Recognizes reports whether the reference looks like this style
+	Recognizes(ref string) bool
+}
+
+// citationStyles lists the profiles; the first one is the default
+var citationStyles = []CitationStyle{kmkStyle{}, apaStyle{}, gostStyle{}}
+
+func citationStyleByName(name string) (CitationStyle, bool) {
+	for _, s := range citationStyles {
+		if s.Name() == name {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// citationStyleFor selects the profile for an article of the journal with the given references
+func citationStyleFor(journal JournalEntry, refs []string) (CitationStyle, error) {
+	name := strings.ToLower(strings.TrimSpace(os.Getenv("CITATION_STYLE")))
+	if name == "" {
+		name = journal.CitationStyle
+	}
+	if name == "" {
+		name = defaultCitationStyle
+	}
+	if name == autoCitationStyle {
+		return detectCitationStyle(refs), nil
+	}
+	style, ok := citationStyleByName(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown citation style %q (known: kmk, apa, gost, auto)", name)
+	}
+	return style, nil
+}
+
+// detectCitationStyle picks the profile that recognizes most references
+func detectCitationStyle(refs []string) CitationStyle {
+	best, bestCount := citationStyles[0], 0
+	for _, style := range citationStyles {
+		count := 0
+		for _, ref := range refs {
+			if style.Recognizes(ref) {
+				count++
+			}
+		}
+		if count > bestCount {
+			best, bestCount = style, count
+		}
+	}
+	if len(refs) == 0 || float64(bestCount)/float64(len(refs)) < citationStyleMinMatches {
+		return citationStyles[0]
+	}
+	return best
+}
+
+// kmkStyle is the journals' own style, parsed by parseReference
+type kmkStyle struct{}
+
+// "Abramov S.A. 2014. Title": the year follows the authors and ends with a period;
+// not in parentheses (APA) and not after a dash (GOST "– 2001.")
+var kmkYearRegex = regexp.MustCompile(`^[^()–—]*?\s(?:19|20)\d{2}[a-z]?\.\s`)
+
+func (kmkStyle) Name() string                     { return "kmk" }
+func (kmkStyle) Parse(ref string) ParsedReference { return parseReference(ref) }
+func (kmkStyle) Recognizes(ref string) bool       { return kmkYearRegex.MatchString(strings.TrimSpace(ref)) }
+
+// finishStyledReference fills the fields every profile derives the same way
+func finishStyledReference(pr *ParsedReference, trail []string) {
+	pr.Title, pr.TitleTranslated = splitTranslatedTitle(pr.Title, pr.Language)
+	pr.AuthorList, pr.EtAl = splitReferenceAuthors(pr.Authors)
+	parseReferenceMeta(pr)
+	scoreReference(pr, trail)
+}
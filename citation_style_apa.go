@@ -0,0 +1,106 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// apaStyle parses APA references:
+//
+//	Smith, J. A., & Brown, K. (2010). Title of the article. Journal Name, 12(3), 45–67. https://doi.org/...
+//	Smith, J. A. (2010). Title of the book (2nd ed.). Publisher.
+//	Smith, J. A. (2010). Title of the chapter. In A. Editor (Ed.), Book title (pp. 1–20). Publisher.
+type apaStyle struct{}
+
+var (
+	// authors, "(2010)" or "(2010a)" or "(2010, March 5)", the rest
+	apaRegex = regexp.MustCompile(`^(.*?)\s*\(((?:19|20)\d{2})[a-z]?(?:,[^)]*)?\)\.\s*(.*)$`)
+	// "Journal Name, 12(3), 45–67" or "Journal Name, 12, e1234"
+	apaSourceRegex = regexp.MustCompile(`^(.+?),\s*(\d+)\s*(?:\(([^)]+)\))?(?:,\s*([A-Za-z]?\d+(?:\s*[-–—]\s*[A-Za-z]?\d+)?))?`)
+	// "In A. Editor (Ed.), Book title (pp. 1–20). Publisher."
+	apaChapterRegex = regexp.MustCompile(`^In\s+.*?\(Eds?\.\),\s*(.+?)\s*\(pp?\.\s*([^)]+)\)\.?\s*(.*)$`)
+)
+
+const splitAPA = "APA pattern"
+
+func (apaStyle) Name() string { return "apa" }
+
+func (apaStyle) Recognizes(ref string) bool {
+	return apaRegex.MatchString(strings.TrimSpace(ref))
+}
+
+func (s apaStyle) Parse(ref string) ParsedReference {
+	ref = strings.Join(strings.Fields(ref), " ")
+	ref, lang := stripLanguageNote(ref)
+
+	m := apaRegex.FindStringSubmatch(ref)
+	if m == nil {
+		pr := parseReference(ref)
+		pr.Heuristics = append(pr.Heuristics, "APA pattern not matched, parsed as kmk")
+		return pr
+	}
+
+	pr := ParsedReference{Authors: strings.TrimSpace(m[1]), Year: m[2], Language: lang}
+	if pr.Authors == "" {
+		pr.Authors = "Not mentioned"
+	}
+	pr.Title, pr.Meta = apaSplitTitle(m[3])
+
+	switch cm := apaChapterRegex.FindStringSubmatch(pr.Meta); {
+	case cm != nil:
+		pr.Type = TypeChapter
+		pr.Container = cm[1]
+		pr.Pages = normalizeRange(cm[2])
+		pr.Publisher = apaPublisher(cm[3])
+	case apaSourceRegex.MatchString(pr.Meta):
+		sm := apaSourceRegex.FindStringSubmatch(pr.Meta)
+		pr.Type = TypeArticle
+		pr.Container = strings.TrimSpace(sm[1])
+		pr.Volume = sm[2]
+		pr.Issue = normalizeRange(sm[3])
+		pr.Pages = normalizeRange(sm[4])
+	case refURLRegex.MatchString(pr.Meta) && normalizeDOI(pr.Meta) == "":
+		pr.Type = TypeOnline
+	default:
+		pr.Type = TypeBook
+		// APA 7 books give only the publisher: "Academic Press."; APA 6 "New York, NY: Academic Press." is left to parseReferenceMeta
+		if !strings.Contains(stripReferenceIDs(pr.Meta), ":") {
+			pr.Publisher = apaPublisher(pr.Meta)
+		}
+	}
+
+	finishStyledReference(&pr, []string{splitAPA})
+	return pr
+}
+
+// apaSplitTitle splits "Title of the article. Journal Name, 12(3), 45–67." at the end of the title:
+// the first ".", "?" or "!" followed by a capital letter that is not an abbreviation
+func apaSplitTitle(s string) (string, string) {
+	for i := 0; i < len(s); i++ {
+		if s[i] != '.' && s[i] != '?' && s[i] != '!' {
+			continue
+		}
+		if s[i] == '.' && isAbbreviation(s, i) {
+			continue
+		}
+		rest := strings.TrimLeft(s[i+1:], " ")
+		if len(rest) == len(s[i+1:]) {
+			continue // no space after: "e.g.", "3.5"
+		}
+		if r, _ := utf8.DecodeRuneInString(rest); unicode.IsUpper(r) || unicode.IsDigit(r) {
+			title := strings.TrimSpace(s[:i+1])
+			if s[i] == '.' {
+				title = strings.TrimSuffix(title, ".")
+			}
+			return title, rest
+		}
+	}
+	return strings.TrimSuffix(strings.TrimSpace(s), "."), ""
+}
+
+// apaPublisher is the publisher sentence without identifiers and the final period
+func apaPublisher(s string) string {
+	return strings.TrimSuffix(strings.TrimSpace(stripReferenceIDs(s)), ".")
+}
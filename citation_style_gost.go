@@ -0,0 +1,95 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// gostStyle parses GOST 7.1 references, areas separated by ". – ":
+//
+//	Иванов И. И. Название статьи / И. И. Иванов, П. П. Петров // Журнал. – 2001. – Т. 80, № 5. – С. 567–575.
+//	Петров П. П. Название : монография / П. П. Петров. – М. : Наука, 1999. – 250 с.
+//	Smith J. Title // Zootaxa. – 2010. – Vol. 2456, No. 3. – P. 12–25.
+type gostStyle struct{}
+
+var (
+	// area separator: ". – ", also typed with an em dash or a hyphen
+	gostAreaSepRegex = regexp.MustCompile(`\.\s+[–—-]\s+`)
+	// heading author before the title: "Иванов И. И.", "Иванов, И. И.", "Smith J."
+	gostHeadingRegex = regexp.MustCompile(`^(\p{Lu}[\p{L}'\-]+(?:\s+\p{Lu}[\p{L}'\-]+)?,?\s+(?:\p{Lu}\p{Ll}?\.\s*){1,3})\s*(.*)$`)
+	// editors in the statement of responsibility: "под ред. П. П. Петрова", "ed. by"
+	gostEditorRegex = regexp.MustCompile(`(?i)(?:под\s+(?:общ\.\s+)?ред\.|отв\.\s+ред\.|сост\.|ed\.\s+by|eds?\.)`)
+	gostYearRegex   = regexp.MustCompile(`(?:^|[^\d])((?:19|20)\d{2})(?:[^\d]|$)`)
+	// " : " before a publisher: "М. : Наука"
+	gostPublisherRegex = regexp.MustCompile(`\S\s+:\s+\S`)
+)
+
+const splitGOST = "GOST 7.1 areas"
+
+func (gostStyle) Name() string { return "gost" }
+
+func (gostStyle) Recognizes(ref string) bool {
+	ref = strings.TrimSpace(ref)
+	return gostAreaSepRegex.MatchString(ref) && !kmkYearRegex.MatchString(ref)
+}
+
+func (s gostStyle) Parse(ref string) ParsedReference {
+	ref = strings.Join(strings.Fields(ref), " ")
+	ref, lang := stripLanguageNote(ref)
+
+	areas := gostAreaSepRegex.Split(strings.TrimSuffix(ref, "."), -1)
+	if len(areas) < 2 {
+		pr := parseReference(ref)
+		pr.Heuristics = append(pr.Heuristics, "no GOST areas, parsed as kmk")
+		return pr
+	}
+
+	pr := ParsedReference{Language: lang, Authors: "Not mentioned", Year: "Not mentioned"}
+	rest := strings.Join(areas[1:], ". – ")
+
+	body, container, isPart := strings.Cut(areas[0], " // ")
+	titlePart, responsibility, _ := strings.Cut(body, " / ")
+
+	heading := ""
+	if m := gostHeadingRegex.FindStringSubmatch(titlePart); m != nil && m[2] != "" {
+		heading, titlePart = strings.TrimSpace(m[1]), m[2]
+	}
+	pr.Title = strings.ReplaceAll(strings.TrimSpace(titlePart), " : ", ": ")
+
+	// The statement of responsibility lists all authors; the heading only the first
+	if responsibility = strings.TrimSpace(responsibility); responsibility != "" && !gostEditorRegex.MatchString(responsibility) && isLikelyAuthorBlock(responsibility) {
+		pr.Authors = responsibility
+	} else if heading != "" {
+		pr.Authors = heading
+	}
+
+	if m := gostYearRegex.FindStringSubmatch(rest); m != nil {
+		pr.Year = m[1]
+	}
+
+	if isPart {
+		pr.Meta = container + ". – " + rest
+		// "Насекомые Сибири : сб. статей / под ред. П. П. Петрова" -> "Насекомые Сибири"
+		container, _, _ = strings.Cut(container, " / ")
+		container, _, _ = strings.Cut(container, " : ")
+		pr.Container = strings.TrimSpace(container)
+	} else {
+		pr.Meta = rest
+	}
+
+	switch {
+	case isPart && gostPublisherRegex.MatchString(rest):
+		pr.Type = TypeChapter
+	case isPart:
+		pr.Type = TypeArticle
+	case refURLRegex.MatchString(rest):
+		pr.Type = TypeOnline
+	case gostPublisherRegex.MatchString(rest):
+		pr.Type = TypeBook
+	default:
+		pr.Type = TypeOther
+	}
+
+	finishStyledReference(&pr, []string{splitGOST})
+	return pr
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// styleCase is the part of a parsed reference the profile tests check
+type styleCase struct {
+	ref       string
+	typ       string
+	authors   []RefAuthor
+	etAl      bool
+	year      string
+	title     string
+	container string
+	volume    string
+	issue     string
+	pages     string
+}
+
+func checkStyle(t *testing.T, style CitationStyle, tests []styleCase) {
+	t.Helper()
+	for _, tt := range tests {
+		if !style.Recognizes(tt.ref) {
+			t.Errorf("%s does not recognize %q", style.Name(), tt.ref)
+		}
+		pr := style.Parse(tt.ref)
+		got := styleCase{ref: tt.ref, typ: pr.Type.String(), authors: pr.AuthorList, etAl: pr.EtAl, year: pr.Year,
+			title: pr.Title, container: pr.Container, volume: pr.Volume, issue: pr.Issue, pages: pr.Pages}
+		if !reflect.DeepEqual(got, tt) {
+			t.Errorf("%s.Parse(%q):\n got %+v\nwant %+v", style.Name(), tt.ref, got, tt)
+		}
+	}
+}
+
+func TestAPAStyle(t *testing.T) {
+	checkStyle(t, apaStyle{}, []styleCase{
+		{
+			// "Surname, I. I., & Surname, I." is inverted to surname and initials
+			ref: "Smith, J. A., & Brown, K. (2010). A new species of Carabus. Zootaxa, 2456(3), 12–25.",
+			typ: "article", authors: []RefAuthor{{"Smith", "J.A."}, {"Brown", "K."}}, year: "2010",
+			title: "A new species of Carabus", container: "Zootaxa", volume: "2456", issue: "3", pages: "12–25",
+		},
+		{
+			ref: "Hebert, P. D. N., Cywinska, A., Ball, S. L., et al. (2003). Biological identifications through DNA barcodes. Proceedings of the Royal Society B, 270, 313–321.",
+			typ: "article", authors: []RefAuthor{{"Hebert", "P.D.N."}, {"Cywinska", "A."}, {"Ball", "S.L."}}, etAl: true, year: "2003",
+			title: "Biological identifications through DNA barcodes", container: "Proceedings of the Royal Society B", volume: "270", pages: "313–321",
+		},
+		{
+			ref: "Smith, J. A. (2010a). Title of the chapter. In A. Editor (Ed.), Book title (pp. 1–20). Academic Press.",
+			typ: "chapter", authors: []RefAuthor{{"Smith", "J.A."}}, year: "2010",
+			title: "Title of the chapter", container: "Book title", pages: "1–20",
+		},
+		{
+			ref: "Smith, J. A. (2010). Ground beetles of Europe (2nd ed.). Academic Press.",
+			typ: "book", authors: []RefAuthor{{"Smith", "J.A."}}, year: "2010", title: "Ground beetles of Europe (2nd ed.)",
+		},
+	})
+}
+
+func TestGOSTStyle(t *testing.T) {
+	checkStyle(t, gostStyle{}, []styleCase{
+		{
+			// authors from the statement of responsibility after "/", the container after "//"
+			ref: "Иванов И. И. Название статьи / И. И. Иванов, П. П. Петров // Журнал. – 2001. – Т. 80, № 5. – С. 567–575.",
+			typ: "article", authors: []RefAuthor{{"Иванов", "И.И."}, {"Петров", "П.П."}}, year: "2001",
+			title: "Название статьи", container: "Журнал", volume: "80", issue: "5", pages: "567–575",
+		},
+		{
+			ref: "Иванов И. И. Название статьи / И. И. Иванов, П. П. Петров, С. С. Сидоров и др. // Журнал. – 2001. – Т. 80, № 5. – С. 567–575.",
+			typ: "article", authors: []RefAuthor{{"Иванов", "И.И."}, {"Петров", "П.П."}, {"Сидоров", "С.С."}}, etAl: true, year: "2001",
+			title: "Название статьи", container: "Журнал", volume: "80", issue: "5", pages: "567–575",
+		},
+		{
+			// areas separated with em dashes
+			ref: "Smith J. Title of the paper // Zootaxa. — 2010. — Vol. 2456, No. 3. — P. 12–25.",
+			typ: "article", authors: []RefAuthor{{"Smith", "J."}}, year: "2010",
+			title: "Title of the paper", container: "Zootaxa", volume: "2456", issue: "3", pages: "12–25",
+		},
+		{
+			// no "//": a book, the heading author alone when the responsibility is missing
+			ref: "Петров П. П. Жужелицы Сибири : монография. – М. : Наука, 1999. – 250 с.",
+			typ: "book", authors: []RefAuthor{{"Петров", "П.П."}}, year: "1999", title: "Жужелицы Сибири: монография",
+		},
+	})
+}
+
+func TestCitationStyleFor(t *testing.T) {
+	refs := []string{
+		"Smith, J. A. (2010). Title. Zootaxa, 2456, 12–25.",
+		"Brown, K. (2011). Title. Zootaxa, 2457, 1–5.",
+	}
+	eej, _ := journalByCode("EEJ")
+	tests := []struct {
+		name  string
+		env   string
+		state JournalState
+		code  string
+		want  string
+	}{
+		{name: "default", code: "EEJ", want: "kmk"},
+		{name: "state file", code: "EEJ", state: JournalState{CitationStyle: "GOST"}, want: "gost"},
+		{name: "environment over state file", env: "apa", code: "EEJ", state: JournalState{CitationStyle: "gost"}, want: "apa"},
+		{name: "auto in the state file", code: "EEJ", state: JournalState{CitationStyle: "auto"}, want: "apa"},
+		{name: "journal outside the registry", code: "PARTNER", state: JournalState{JournalName: "Partner Journal", CitationStyle: "apa"}, want: "apa"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CITATION_STYLE", tt.env)
+			journal := configuredJournal(tt.code, &tt.state)
+			style, err := citationStyleFor(journal, refs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if style.Name() != tt.want {
+				t.Errorf("style %s, want %s", style.Name(), tt.want)
+			}
+			if tt.code == "EEJ" && (journal.Name != eej.Name || journal.Pages != eej.Pages) {
+				t.Errorf("configured journal %+v lost its registry entry", journal)
+			}
+		})
+	}
+
+	t.Run("unknown style in the state file", func(t *testing.T) {
+		t.Setenv("CITATION_STYLE", "")
+		if _, err := citationStyleFor(configuredJournal("EEJ", &JournalState{CitationStyle: "harvard"}), refs); err == nil {
+			t.Error("no error for an unknown style")
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+/*
+CONVERSION LOG
+
+What processDocument prints (segmentation, article parse results, warnings,
+allocated numbers) is what the editor needs to fix the document. Every line
+goes through the conversion's ConversionLog: it is printed to the server log as
+before and, for a job, kept with the job and streamed to the browser:
+
+	GET /api/jobs/:id/events    server-sent events, "log" per line and "end"
+	                            with the job once it is done or failed
+
+The stream starts with the lines logged so far, so a page that connects late
+or reconnects gets the whole log. A job keeps at most maxJobLogEntries lines;
+the rest is only in the server log. Levels: printError and lines starting with ❌
+are errors, printWarning and lines starting with ⚠️ or "Warning" are warnings.
+*/
+
+// Log levels of LogEntry
+const (
+	logInfo    = "info"
+	logWarning = "warning"
+	logError   = "error"
+)
+
+// LogEntry is one line of the conversion log
+type LogEntry struct {
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`             // info, warning or error
+	Article int       `json:"article,omitempty"` // for printWarning and printError
+	Message string    `json:"message"`
+}
+
+// ConversionLog receives the console output and stages of one conversion.
+// A nil *ConversionLog only prints to the console (CLI, tests, /api/convert).
+type ConversionLog struct {
+	onStage    func(ConversionStage)
+	onEntry    func(LogEntry)
+	unattended bool // nobody reads the console, questions on stdin would block (jobs)
+}
+
+// attended tells whether the conversion may ask on stdin
+func (l *ConversionLog) attended() bool {
+	return l == nil || !l.unattended
+}
+
+func (l *ConversionLog) Printf(format string, args ...any) {
+	l.write(0, "", fmt.Sprintf(format, args...))
+}
+
+func (l *ConversionLog) Println(args ...any) {
+	l.write(0, "", fmt.Sprintln(args...))
+}
+
+func (l *ConversionLog) printError(artNum int, message string) {
+	l.write(artNum, logError, fmt.Sprintf("⚠️  [Article %d] ERROR: %s\n", artNum, message))
+}
+
+func (l *ConversionLog) printWarning(artNum int, field string, message string) {
+	l.write(artNum, logWarning, fmt.Sprintf("⚠️  [Article %d] WARNING - %s: %s\n", artNum, field, message))
+}
+
+// stage reports progress; current and total count articles in per-article stages
+func (l *ConversionLog) stage(name string, current, total int) {
+	if l != nil && l.onStage != nil {
+		l.onStage(ConversionStage{Name: name, Current: current, Total: total})
+	}
+}
+
+// write prints text and passes every non-empty line on; level "" is taken from the line
+func (l *ConversionLog) write(artNum int, level, text string) {
+	fmt.Print(text)
+	if l == nil || l.onEntry == nil {
+		return
+	}
+	now := time.Now()
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lineLevel := level
+		if lineLevel == "" {
+			lineLevel = logLevelOf(line)
+		}
+		l.onEntry(LogEntry{Time: now, Level: lineLevel, Article: artNum, Message: line})
+	}
+}
+
+// logLevelOf classifies a console line by its prefix
+func logLevelOf(line string) string {
+	line = strings.TrimSpace(line)
+	switch {
+	case strings.HasPrefix(line, "❌"):
+		return logError
+	case strings.HasPrefix(line, "⚠️"), strings.HasPrefix(line, "Warning"):
+		return logWarning
+	}
+	return logInfo
+}
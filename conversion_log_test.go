@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sseEvent is one server-sent event as read from the stream
+type sseEvent struct {
+	name string
+	data string
+}
+
+// readSSE reads events until the server closes the stream
+func readSSE(t *testing.T, r *bufio.Reader) []sseEvent {
+	t.Helper()
+	var events []sseEvent
+	var cur sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return events
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			cur.name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			cur.data += strings.TrimPrefix(line, "data:")
+		case line == "" && cur.name != "":
+			events = append(events, cur)
+			cur = sseEvent{}
+		}
+	}
+}
+
+func TestJobEventsStream(t *testing.T) {
+	release := make(chan struct{})
+	q := startJobQueue(1, time.Hour, func(inputPath, outputPath string, clog *ConversionLog) error {
+		clog.Printf("Articles found: %d\n", 2)
+		clog.printWarning(1, "KEYWORDS", "Keywords section not found")
+		<-release
+		clog.Printf("⚠️  SEGMENTATION: markers give 2 articles, document structure suggests 1\n")
+		clog.printError(2, "ABSTRACT section not found in article text")
+		return errors.New("failed to save Excel file")
+	})
+	srv := httptest.NewServer(useJobQueue(t, q))
+	defer srv.Close()
+
+	job, err := q.Submit("issue.docx", filepath.Join(t.TempDir(), "issue.docx"), filepath.Join(t.TempDir(), "out.xlsx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Connect late: the first lines are logged already and must be replayed
+	waitForJob(t, q, job.ID, func(Job) bool {
+		entries, _, _, _ := q.Log(job.ID, 0)
+		return len(entries) == 2
+	})
+
+	resp, err := http.Get(srv.URL + "/api/jobs/" + job.ID + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		t.Errorf("Content-Type %q, want text/event-stream", ct)
+	}
+	close(release)
+	events := readSSE(t, bufio.NewReader(resp.Body))
+
+	want := []struct {
+		level   string
+		article int
+		prefix  string
+	}{
+		{logInfo, 0, "Articles found: 2"},
+		{logWarning, 1, "⚠️  [Article 1] WARNING - KEYWORDS"},
+		{logWarning, 0, "⚠️  SEGMENTATION"},
+		{logError, 2, "⚠️  [Article 2] ERROR: ABSTRACT"},
+		{logError, 0, "❌ Failed to process document: failed to save Excel file"},
+	}
+	if len(events) != len(want)+1 {
+		t.Fatalf("%d events, want %d log events and end: %v", len(events), len(want), events)
+	}
+	for i, w := range want {
+		var entry LogEntry
+		if events[i].name != "log" {
+			t.Errorf("event %d is %q, want log", i+1, events[i].name)
+			continue
+		}
+		if err := json.Unmarshal([]byte(events[i].data), &entry); err != nil {
+			t.Fatalf("event %d: %v", i+1, err)
+		}
+		if entry.Level != w.level || entry.Article != w.article || !strings.HasPrefix(entry.Message, w.prefix) {
+			t.Errorf("event %d = %s article %d %q, want %s article %d %q…",
+				i+1, entry.Level, entry.Article, entry.Message, w.level, w.article, w.prefix)
+		}
+	}
+
+	end := events[len(events)-1]
+	var finished Job
+	if end.name != "end" || json.Unmarshal([]byte(end.data), &finished) != nil {
+		t.Fatalf("last event %q %s, want end with the job", end.name, end.data)
+	}
+	if finished.ID != job.ID || finished.Status != JobFailed {
+		t.Errorf("end event job %s is %s, want %s failed", finished.ID, finished.Status, job.ID)
+	}
+}
+
+func TestLogLevelOf(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"✓ [Article 1] Parsed successfully", logInfo},
+		{"⚠️  MARKERS: line 3: empty reference block", logWarning},
+		{"Warning: No affiliation data found for article 3", logWarning},
+		{"❌ MARKERS: line 7: \"<<<\" inside a reference block", logError},
+		{"  ❌ indented", logError},
+	}
+	for _, tt := range tests {
+		if got := logLevelOf(tt.line); got != tt.want {
+			t.Errorf("logLevelOf(%q) = %s, want %s", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestJobLogCapped(t *testing.T) {
+	job := &Job{logChanged: make(chan struct{})}
+	for i := 0; i < maxJobLogEntries+10; i++ {
+		job.appendLog(LogEntry{Level: logInfo, Message: fmt.Sprintf("line %d", i)})
+	}
+	if len(job.log) != maxJobLogEntries+1 {
+		t.Fatalf("%d log entries, want %d and the truncation warning", len(job.log), maxJobLogEntries)
+	}
+	if last := job.log[maxJobLogEntries]; last.Level != logWarning || !strings.Contains(last.Message, "truncated") {
+		t.Errorf("last entry %+v, want the truncation warning", last)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+/*
+CORRIGENDA
+
+A correction ("Corrigendum to ...", "Erratum", "Исправление к ...") must be linked
+to the article it corrects: Crossref expects an update relation to the original
+DOI. For items of type erratum (see article_type.go):
+
+- the item's own DOI is the header DOI of this issue (same volume and issue as
+  its citation line); any other DOI in the header is the original
+- without a second DOI the original is kept as a citation for the editor: the
+  "Corrigendum to: ..." / "Original article: ..." line, else the title after
+  "Corrigendum to"
+- the update type follows the wording: corrigendum, erratum or correction
+
+The relation goes to articles.updates_doi, articles.updates_type and
+articles.updates_citation, and from there into the crossmark/updates section
+of the Crossref deposit (crossref_export.go).
+*/
+
+// ArticleUpdate is the relation of a correction to the article it corrects
+type ArticleUpdate struct {
+	DOI      string // original article, "" when only the citation is known
+	Type     string // Crossref update type: corrigendum, erratum or correction
+	Citation string // how the item refers to the original
+}
+
+// target names the corrected article for messages
+func (u ArticleUpdate) target() string {
+	switch {
+	case u.DOI != "":
+		return u.DOI
+	case u.Citation != "":
+		return fmt.Sprintf("%q", u.Citation)
+	}
+	return "an unknown article"
+}
+
+var (
+	// "Corrigendum to: Ivanova A.B. 2024. ...", "Original article: ...", "Исправление к статье: ..."
+	corrigendumLineRegex = regexp.MustCompile(`(?i)^(?:(?:corrigendum|erratum|correction)\s+(?:to|of)|original\s+article|(?:исправление|поправка)\s+к(?:\s+статье)?)\s*[:.]?\s*(.+)$`)
+	// the same words at the start of the title
+	corrigendumTitleRegex = regexp.MustCompile(`(?i)^(?:(?:corrigendum|erratum|correction)\s+(?:to|of)|(?:исправление|поправка)\s+к(?:\s+статье)?)\s*[:.]?\s*(.*)$`)
+	updateTypeRegex       = regexp.MustCompile(`(?i)corrigend|errat|correction|исправлени|поправк`)
+)
+
+// linkCorrigendum picks the item's own DOI and the corrected article from the header lines.
+// dois are the header DOIs in order of appearance.
+func linkCorrigendum(lines, dois []string, citation articleCitation) (own string, update ArticleUpdate, problems []string) {
+	own = ownDOI(dois, citation)
+	for _, d := range dois {
+		if d != own {
+			update.DOI = d
+			break
+		}
+	}
+	// DOIs of the original are often given as plain links, without a "DOI" label
+	if update.DOI == "" {
+		for _, line := range lines {
+			for _, d := range doiCoreRegex.FindAllString(line, -1) {
+				if d = normalizeDOI(d); d != own {
+					update.DOI = d
+					break
+				}
+			}
+			if update.DOI != "" {
+				break
+			}
+		}
+	}
+
+	for _, line := range lines {
+		if m := corrigendumLineRegex.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			update.Citation = strings.TrimSpace(m[1])
+			break
+		}
+	}
+	if update.Citation == "" {
+		if m := corrigendumTitleRegex.FindStringSubmatch(citation.title); m != nil {
+			update.Citation = strings.Trim(strings.TrimSpace(m[1]), `"'“”«»`)
+		}
+	}
+
+	update.Type = updateType(citation.title)
+	if update.DOI == "" {
+		problems = append(problems, "DOI of the corrected article not found, only the citation is kept")
+	}
+	if update.DOI == "" && update.Citation == "" {
+		problems = append(problems, "Corrected article is not named: add its DOI to the item")
+	}
+	if update.DOI != "" && update.DOI == own {
+		problems = append(problems, fmt.Sprintf("Correction and corrected article have the same DOI %s", own))
+	}
+	return own, update, problems
+}
+
+// ownDOI is the header DOI that belongs to the citation line's volume and issue, else the first one
+func ownDOI(dois []string, citation articleCitation) string {
+	for _, d := range dois {
+		parsed, err := parseJournalDOI(d)
+		if err == nil && parsed.Volume == citation.volume && (citation.issue == 0 || parsed.Issue == citation.issue) {
+			return d
+		}
+	}
+	if len(dois) > 0 {
+		return dois[0]
+	}
+	return ""
+}
+
+// updateType maps the wording of the title to a Crossref update type
+func updateType(title string) string {
+	switch word := strings.ToLower(updateTypeRegex.FindString(title)); {
+	case word == "corrigend":
+		return "corrigendum"
+	case word == "errat":
+		return "erratum"
+	default:
+		return "correction"
+	}
+}
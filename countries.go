@@ -0,0 +1,238 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Country is a gazetteer entry: ISO 3166-1 alpha-2 code, English and Russian names.
+// The first English name is the canonical one.
+type Country struct {
+	Code    string
+	Names   []string
+	NamesRu []string
+}
+
+// countryGazetteer lists all countries of Europe and Asia, plus those of the
+// other continents our authors come from. Extra spellings found in
+// affiliations are listed as names.
+var countryGazetteer = []Country{
+	{"RU", []string{"Russia", "Russian Federation"}, []string{"Россия", "Российская Федерация", "РФ"}},
+	{"KZ", []string{"Kazakhstan"}, []string{"Казахстан"}},
+	{"BY", []string{"Belarus", "Byelorussia"}, []string{"Беларусь", "Белоруссия"}},
+	{"UA", []string{"Ukraine"}, []string{"Украина"}},
+	{"UZ", []string{"Uzbekistan"}, []string{"Узбекистан"}},
+	{"KG", []string{"Kyrgyzstan", "Kirghizia", "Kyrgyz Republic"}, []string{"Киргизия", "Кыргызстан"}},
+	{"TJ", []string{"Tajikistan"}, []string{"Таджикистан"}},
+	{"TM", []string{"Turkmenistan"}, []string{"Туркменистан", "Туркмения"}},
+	{"AZ", []string{"Azerbaijan"}, []string{"Азербайджан"}},
+	{"AM", []string{"Armenia"}, []string{"Армения"}},
+	{"GE", []string{"Georgia"}, []string{"Грузия"}},
+	{"MD", []string{"Moldova"}, []string{"Молдова", "Молдавия"}},
+	{"MN", []string{"Mongolia"}, []string{"Монголия"}},
+	{"CN", []string{"China", "P.R. China", "PR China", "People's Republic of China"}, []string{"Китай", "КНР"}},
+	{"JP", []string{"Japan"}, []string{"Япония"}},
+	{"KR", []string{"South Korea", "Republic of Korea", "Korea"}, []string{"Южная Корея", "Республика Корея", "Корея"}},
+	{"KP", []string{"North Korea", "DPR Korea"}, []string{"КНДР", "Северная Корея"}},
+	{"VN", []string{"Vietnam", "Viet Nam"}, []string{"Вьетнам"}},
+	{"TH", []string{"Thailand"}, []string{"Таиланд"}},
+	{"LA", []string{"Laos", "Lao PDR"}, []string{"Лаос"}},
+	{"KH", []string{"Cambodia"}, []string{"Камбоджа"}},
+	{"MM", []string{"Myanmar", "Burma"}, []string{"Мьянма"}},
+	{"MY", []string{"Malaysia"}, []string{"Малайзия"}},
+	{"SG", []string{"Singapore"}, []string{"Сингапур"}},
+	{"ID", []string{"Indonesia"}, []string{"Индонезия"}},
+	{"PH", []string{"Philippines"}, []string{"Филиппины"}},
+	{"TW", []string{"Taiwan"}, []string{"Тайвань"}},
+	{"IN", []string{"India"}, []string{"Индия"}},
+	{"PK", []string{"Pakistan"}, []string{"Пакистан"}},
+	{"NP", []string{"Nepal"}, []string{"Непал"}},
+	{"LK", []string{"Sri Lanka"}, []string{"Шри-Ланка"}},
+	{"BD", []string{"Bangladesh"}, []string{"Бангладеш"}},
+	{"AF", []string{"Afghanistan"}, []string{"Афганистан"}},
+	{"IR", []string{"Iran"}, []string{"Иран"}},
+	{"IQ", []string{"Iraq"}, []string{"Ирак"}},
+	{"TR", []string{"Turkey", "Türkiye", "Turkiye"}, []string{"Турция"}},
+	{"IL", []string{"Israel"}, []string{"Израиль"}},
+	{"SA", []string{"Saudi Arabia"}, []string{"Саудовская Аравия"}},
+	{"AE", []string{"United Arab Emirates", "UAE"}, []string{"ОАЭ"}},
+	{"JO", []string{"Jordan"}, []string{"Иордания"}},
+	{"LB", []string{"Lebanon"}, []string{"Ливан"}},
+	{"SY", []string{"Syria", "Syrian Arab Republic"}, []string{"Сирия"}},
+	{"PS", []string{"Palestine"}, []string{"Палестина"}},
+	{"KW", []string{"Kuwait"}, []string{"Кувейт"}},
+	{"QA", []string{"Qatar"}, []string{"Катар"}},
+	{"BH", []string{"Bahrain"}, []string{"Бахрейн"}},
+	{"OM", []string{"Oman"}, []string{"Оман"}},
+	{"YE", []string{"Yemen"}, []string{"Йемен"}},
+	{"BT", []string{"Bhutan"}, []string{"Бутан"}},
+	{"MV", []string{"Maldives"}, []string{"Мальдивы"}},
+	{"BN", []string{"Brunei", "Brunei Darussalam"}, []string{"Бруней"}},
+	{"TL", []string{"Timor-Leste", "East Timor"}, []string{"Восточный Тимор"}},
+	{"EG", []string{"Egypt"}, []string{"Египет"}},
+	{"DE", []string{"Germany"}, []string{"Германия"}},
+	{"AT", []string{"Austria"}, []string{"Австрия"}},
+	{"CH", []string{"Switzerland"}, []string{"Швейцария"}},
+	{"FR", []string{"France"}, []string{"Франция"}},
+	{"BE", []string{"Belgium"}, []string{"Бельгия"}},
+	{"NL", []string{"Netherlands", "The Netherlands", "Holland"}, []string{"Нидерланды"}},
+	{"LU", []string{"Luxembourg"}, []string{"Люксембург"}},
+	{"GB", []string{"United Kingdom", "UK", "U.K.", "Great Britain", "England", "Scotland", "Wales"}, []string{"Великобритания", "Англия"}},
+	{"IE", []string{"Ireland"}, []string{"Ирландия"}},
+	{"IT", []string{"Italy"}, []string{"Италия"}},
+	{"ES", []string{"Spain"}, []string{"Испания"}},
+	{"PT", []string{"Portugal"}, []string{"Португалия"}},
+	{"GR", []string{"Greece"}, []string{"Греция"}},
+	{"PL", []string{"Poland"}, []string{"Польша"}},
+	{"CZ", []string{"Czech Republic", "Czechia"}, []string{"Чехия"}},
+	{"SK", []string{"Slovakia"}, []string{"Словакия"}},
+	{"HU", []string{"Hungary"}, []string{"Венгрия"}},
+	{"RO", []string{"Romania"}, []string{"Румыния"}},
+	{"BG", []string{"Bulgaria"}, []string{"Болгария"}},
+	{"RS", []string{"Serbia"}, []string{"Сербия"}},
+	{"HR", []string{"Croatia"}, []string{"Хорватия"}},
+	{"SI", []string{"Slovenia"}, []string{"Словения"}},
+	{"BA", []string{"Bosnia and Herzegovina"}, []string{"Босния и Герцеговина"}},
+	{"MK", []string{"North Macedonia", "Macedonia"}, []string{"Северная Македония", "Македония"}},
+	{"AL", []string{"Albania"}, []string{"Албания"}},
+	{"ME", []string{"Montenegro"}, []string{"Черногория"}},
+	{"XK", []string{"Kosovo"}, []string{"Косово"}},
+	{"CY", []string{"Cyprus"}, []string{"Кипр"}},
+	{"MT", []string{"Malta"}, []string{"Мальта"}},
+	{"AD", []string{"Andorra"}, []string{"Андорра"}},
+	{"MC", []string{"Monaco"}, []string{"Монако"}},
+	{"LI", []string{"Liechtenstein"}, []string{"Лихтенштейн"}},
+	{"SM", []string{"San Marino"}, []string{"Сан-Марино"}},
+	{"VA", []string{"Vatican", "Vatican City", "Holy See"}, []string{"Ватикан"}},
+	{"DK", []string{"Denmark"}, []string{"Дания"}},
+	{"SE", []string{"Sweden"}, []string{"Швеция"}},
+	{"NO", []string{"Norway"}, []string{"Норвегия"}},
+	{"FI", []string{"Finland"}, []string{"Финляндия"}},
+	{"IS", []string{"Iceland"}, []string{"Исландия"}},
+	{"EE", []string{"Estonia"}, []string{"Эстония"}},
+	{"LV", []string{"Latvia"}, []string{"Латвия"}},
+	{"LT", []string{"Lithuania"}, []string{"Литва"}},
+	{"US", []string{"USA", "U.S.A.", "United States", "United States of America"}, []string{"США"}},
+	{"CA", []string{"Canada"}, []string{"Канада"}},
+	{"MX", []string{"Mexico"}, []string{"Мексика"}},
+	{"BR", []string{"Brazil"}, []string{"Бразилия"}},
+	{"AR", []string{"Argentina"}, []string{"Аргентина"}},
+	{"CL", []string{"Chile"}, []string{"Чили"}},
+	{"CO", []string{"Colombia"}, []string{"Колумбия"}},
+	{"PE", []string{"Peru"}, []string{"Перу"}},
+	{"CU", []string{"Cuba"}, []string{"Куба"}},
+	{"AU", []string{"Australia"}, []string{"Австралия"}},
+	{"NZ", []string{"New Zealand"}, []string{"Новая Зеландия"}},
+	{"ZA", []string{"South Africa", "Republic of South Africa"}, []string{"ЮАР", "Южная Африка"}},
+	{"KE", []string{"Kenya"}, []string{"Кения"}},
+	{"TZ", []string{"Tanzania"}, []string{"Танзания"}},
+	{"ET", []string{"Ethiopia"}, []string{"Эфиопия"}},
+	{"NG", []string{"Nigeria"}, []string{"Нигерия"}},
+	{"MA", []string{"Morocco"}, []string{"Марокко"}},
+	{"DZ", []string{"Algeria"}, []string{"Алжир"}},
+	{"TN", []string{"Tunisia"}, []string{"Тунис"}},
+	{"MG", []string{"Madagascar"}, []string{"Мадагаскар"}},
+}
+
+// usStates are the US states (and DC) by postal code. US addresses put the
+// state and ZIP code between the city and the country ("Las Cruces, NM 88003 USA"),
+// and "Athens, Georgia 30602" is in the US, not in Georgia.
+var usStates = map[string]string{
+	"AL": "Alabama", "AK": "Alaska", "AZ": "Arizona", "AR": "Arkansas", "CA": "California",
+	"CO": "Colorado", "CT": "Connecticut", "DE": "Delaware", "DC": "District of Columbia",
+	"FL": "Florida", "GA": "Georgia", "HI": "Hawaii", "ID": "Idaho", "IL": "Illinois",
+	"IN": "Indiana", "IA": "Iowa", "KS": "Kansas", "KY": "Kentucky", "LA": "Louisiana",
+	"ME": "Maine", "MD": "Maryland", "MA": "Massachusetts", "MI": "Michigan", "MN": "Minnesota",
+	"MS": "Mississippi", "MO": "Missouri", "MT": "Montana", "NE": "Nebraska", "NV": "Nevada",
+	"NH": "New Hampshire", "NJ": "New Jersey", "NM": "New Mexico", "NY": "New York",
+	"NC": "North Carolina", "ND": "North Dakota", "OH": "Ohio", "OK": "Oklahoma", "OR": "Oregon",
+	"PA": "Pennsylvania", "RI": "Rhode Island", "SC": "South Carolina", "SD": "South Dakota",
+	"TN": "Tennessee", "TX": "Texas", "UT": "Utah", "VT": "Vermont", "VA": "Virginia",
+	"WA": "Washington", "WV": "West Virginia", "WI": "Wisconsin", "WY": "Wyoming",
+}
+
+var (
+	// usStateRegex matches an address part that is a US state, with or without a ZIP code;
+	// usStateZIPRegex only the ones with a ZIP code, which make the address a US one
+	usStateRegex    *regexp.Regexp
+	usStateZIPRegex *regexp.Regexp
+
+	countryRegex  *regexp.Regexp
+	countryByName map[string]*Country
+	// regions named after a country: "University of New Mexico" is not in Mexico
+	countryFalseFriendRegex = regexp.MustCompile(`(?i)\b(?:new\s+mexico|new\s+south\s+wales|new\s+england)\b`)
+)
+
+func init() {
+	countryByName = make(map[string]*Country)
+	var names []string
+	for i := range countryGazetteer {
+		c := &countryGazetteer[i]
+		for _, n := range append(append([]string{}, c.Names...), c.NamesRu...) {
+			countryByName[strings.ToLower(n)] = c
+			names = append(names, regexp.QuoteMeta(n))
+		}
+	}
+	// Longest names first so "South Korea" wins over "Korea"
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+	// Go's \b is ASCII-only, so names are delimited by non-letters
+	countryRegex = regexp.MustCompile(`(?i)(?:^|[^\p{L}])(` + strings.Join(names, "|") + `)(?:[^\p{L}]|$)`)
+
+	var codes, stateNames []string
+	for code, name := range usStates {
+		codes = append(codes, code)
+		stateNames = append(stateNames, strings.ReplaceAll(name, " ", `\s+`))
+	}
+	// codes are upper case only: "in 47405" is not Indiana
+	state := `(?:(?i:` + strings.Join(stateNames, "|") + `)|` + strings.Join(codes, "|") + `)`
+	usStateRegex = regexp.MustCompile(`^` + state + `(?:\s+\d{5}(?:-\d{4})?)?$`)
+	usStateZIPRegex = regexp.MustCompile(`^` + state + `\s+\d{5}(?:-\d{4})?$`)
+}
+
+// Name is the canonical English name
+func (c Country) Name() string {
+	return c.Names[0]
+}
+
+// findCountry returns the country of an address: addresses end with the country,
+// so the comma-separated parts are checked from the last one. A US state with a
+// ZIP code stands for the country.
+func findCountry(s string) (Country, bool) {
+	parts := splitAffiliationParts(s)
+	for i := len(parts) - 1; i >= 0; i-- {
+		if usStateZIPRegex.MatchString(parts[i]) {
+			return *countryByName["usa"], true
+		}
+		if c, loc := lastCountryIndex(parts[i]); loc != nil {
+			return c, true
+		}
+	}
+	return Country{}, false
+}
+
+// lastCountryIndex returns the last country name in s and its position, nil if there is none
+func lastCountryIndex(s string) (Country, []int) {
+	// blank out region names so that they keep their length but match nothing
+	s = countryFalseFriendRegex.ReplaceAllStringFunc(s, func(m string) string {
+		return strings.Repeat(" ", len(m))
+	})
+
+	var found *Country
+	var loc []int
+	offset := 0
+	for {
+		m := countryRegex.FindStringSubmatchIndex(s[offset:])
+		if m == nil {
+			break
+		}
+		found = countryByName[strings.ToLower(s[offset+m[2]:offset+m[3]])]
+		loc = []int{offset + m[2], offset + m[3]}
+		// continue right after the name: its trailing delimiter may start the next one
+		offset = loc[1]
+	}
+	if found == nil {
+		return Country{}, nil
+	}
+	return *found, loc
+}
@@ -0,0 +1,54 @@
+package main
+
+import "testing"
+
+func TestFindCountry(t *testing.T) {
+	tests := []struct {
+		address string
+		want    string // country code, "" for none
+	}{
+		{"Zoological Institute, Universitetskaya Emb. 1, St Petersburg 199034 Russia", "RU"},
+		{"Department of Biology, University of New Mexico, Albuquerque, New Mexico, USA", "US"},
+		{"New Mexico, USA", "US"},
+		{"University of New Mexico, Albuquerque, NM 87131", "US"},
+		{"University of Georgia, Athens, Georgia 30602", "US"},
+		{"Ilia State University, Tbilisi 0162, Georgia", "GE"},
+		{"American University of Beirut, Beirut, Lebanon", "LB"},
+		{"University of Pristina, Pristina 10000, Kosovo", "XK"},
+		{"Università di San Marino, San Marino", "SM"},
+		{"Universidad Nacional Autónoma de México, Mexico City, Mexico", "MX"},
+		{"Australian Museum, Sydney, New South Wales 2000, Australia", "AU"},
+		{"Natural History Museum, London SW7 5BD, UK", "GB"},
+		{"Korea University, Seoul, South Korea", "KR"},
+		{"Институт систематики и экологии животных СО РАН, Новосибирск, Россия", "RU"},
+		{"Indiana University, Bloomington, IN 47405", "US"},
+		{"Indiana University, Bloomington, in 47405", ""},
+		{"Omani Institute, Omsk", ""},
+	}
+	for _, tt := range tests {
+		c, ok := findCountry(tt.address)
+		if got := c.Code; got != tt.want || ok != (tt.want != "") {
+			t.Errorf("findCountry(%q) = %q, %v; want %q", tt.address, got, ok, tt.want)
+		}
+	}
+}
+
+func TestSplitAffiliationCountry(t *testing.T) {
+	tests := []struct {
+		raw, code, city string
+	}{
+		{"Department of Biology, University of New Mexico, Albuquerque, New Mexico, USA", "US", "Albuquerque"},
+		{"Biology Department, New Mexico State University, Las Cruces, NM 88003 USA", "US", "Las Cruces"},
+		{"University of New Mexico, Albuquerque, NM 87131", "US", "Albuquerque"},
+		{"Department of Entomology, University of Georgia, Athens, Georgia 30602", "US", "Athens"},
+		{"University of Georgia, Athens, GA 30602-2603, USA", "US", "Athens"},
+		{"Institute of Zoology, Ilia State University, Tbilisi, Georgia", "GE", "Tbilisi"},
+		{"Tomsk State University, Lenina Prospekt 36, Tomsk 634050 Russia", "RU", "Tomsk"},
+	}
+	for _, tt := range tests {
+		aff := splitAffiliation(tt.raw)
+		if aff.CountryCode != tt.code || aff.City != tt.city {
+			t.Errorf("splitAffiliation(%q): country %q, city %q; want %q, %q", tt.raw, aff.CountryCode, aff.City, tt.code, tt.city)
+		}
+	}
+}
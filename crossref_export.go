@@ -0,0 +1,498 @@
+package main
+
+import (
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+/*
+CROSSREF EXPORT
+
+	server crossref [-o deposit.xml] <workbook.xlsx>
+
+writes a Crossref deposit (schema 5.3.1) for the issue of a converted workbook,
+once the editor has reviewed it: the journal, the issue and for every article
+its title (and Russian title), authors, publication date, pages, DOI and the
+article page from the doi sheet. Both summaries go in as JATS abstracts marked
+xml:lang="en" and xml:lang="ru", so the Russian abstract travels with the
+Russian title. Keywords are not deposited: journal_article has no keyword
+element in this schema (and a JATS abstract takes no kwd-group), so the
+split terms stay on the keywords sheet, one row per term.
+
+The References sheet becomes the article's citation_list, one citation per
+row in sheet order: <doi> when ref.doi was found in the reference or
+ref.suggested_doi was matched (and kept by the editor), <unstructured_citation>
+with ref.full otherwise. The parsed fields go along so Crossref can match
+references without a DOI: the first author from the reference-authors sheet,
+cYear from ref.year, first_page from ref.pages, and the titles by ref.type
+(an article's container is the journal_title, a chapter's the volume_title,
+a book's own title is the volume_title).
+
+Corrections (articles.type erratum) get a crossmark section with an update
+relation to articles.updates_doi, typed by articles.updates_type (see
+corrigenda.go). Crossref only takes a DOI there: a correction that names the
+corrected article by citation only is reported and nothing is written until
+updates_doi is filled in.
+
+Configuration:
+- CROSSREF_DEPOSITOR, CROSSREF_EMAIL: depositor of the batch (required)
+- CROSSREF_CROSSMARK_POLICY: DOI of the journals' Crossmark policy page,
+  required when the issue has corrections
+*/
+
+const crossrefSchemaVersion = "5.3.1"
+
+// crossrefConfig is the depositor information from the environment
+type crossrefConfig struct {
+	Depositor       string
+	Email           string
+	CrossmarkPolicy string
+}
+
+func crossrefConfigFromEnv() crossrefConfig {
+	return crossrefConfig{
+		Depositor:       os.Getenv("CROSSREF_DEPOSITOR"),
+		Email:           os.Getenv("CROSSREF_EMAIL"),
+		CrossmarkPolicy: os.Getenv("CROSSREF_CROSSMARK_POLICY"),
+	}
+}
+
+type crossrefBatch struct {
+	XMLName        xml.Name        `xml:"doi_batch"`
+	Version        string          `xml:"version,attr"`
+	Xmlns          string          `xml:"xmlns,attr"`
+	XmlnsXSI       string          `xml:"xmlns:xsi,attr"`
+	XmlnsJATS      string          `xml:"xmlns:jats,attr"`
+	SchemaLocation string          `xml:"xsi:schemaLocation,attr"`
+	Head           crossrefHead    `xml:"head"`
+	Journal        crossrefJournal `xml:"body>journal"`
+}
+
+type crossrefHead struct {
+	BatchID       string `xml:"doi_batch_id"`
+	Timestamp     string `xml:"timestamp"`
+	DepositorName string `xml:"depositor>depositor_name"`
+	Email         string `xml:"depositor>email_address"`
+	Registrant    string `xml:"registrant"`
+}
+
+type crossrefJournal struct {
+	Metadata struct {
+		Language  string `xml:"language,attr"`
+		FullTitle string `xml:"full_title"`
+	} `xml:"journal_metadata"`
+	Issue    crossrefIssue     `xml:"journal_issue"`
+	Articles []crossrefArticle `xml:"journal_article"`
+}
+
+type crossrefDate struct {
+	MediaType string `xml:"media_type,attr"`
+	Month     string `xml:"month"`
+	Day       string `xml:"day"`
+	Year      string `xml:"year"`
+}
+
+type crossrefIssue struct {
+	PublicationDate crossrefDate `xml:"publication_date"`
+	Volume          string       `xml:"journal_volume>volume"`
+	Issue           string       `xml:"issue"`
+}
+
+type crossrefArticle struct {
+	PublicationType string                 `xml:"publication_type,attr"`
+	Title           string                 `xml:"titles>title"`
+	OriginalTitle   *crossrefOriginalTitle `xml:"titles>original_language_title"`
+	Contributors    []crossrefPerson       `xml:"contributors>person_name"`
+	Abstracts       []crossrefAbstract     `xml:"jats:abstract"`
+	PublicationDate crossrefDate           `xml:"publication_date"`
+	Pages           *crossrefPages         `xml:"pages"`
+	Crossmark       *crossrefCrossmark     `xml:"crossmark"`
+	DOI             string                 `xml:"doi_data>doi"`
+	Resource        string                 `xml:"doi_data>resource"`
+	CitationList    *crossrefCitationList  `xml:"citation_list"`
+}
+
+type crossrefOriginalTitle struct {
+	Language string `xml:"language,attr"`
+	Title    string `xml:",chardata"`
+}
+
+// crossrefAbstract is a JATS abstract, one paragraph per line of the summary
+type crossrefAbstract struct {
+	Language   string   `xml:"xml:lang,attr"`
+	Paragraphs []string `xml:"jats:p"`
+}
+
+type crossrefPerson struct {
+	Sequence  string `xml:"sequence,attr"`
+	Role      string `xml:"contributor_role,attr"`
+	GivenName string `xml:"given_name,omitempty"`
+	Surname   string `xml:"surname"`
+}
+
+type crossrefPages struct {
+	First string `xml:"first_page"`
+	Last  string `xml:"last_page,omitempty"`
+}
+
+// crossrefCrossmark carries the update relation of a correction
+type crossrefCrossmark struct {
+	Version string           `xml:"crossmark_version"`
+	Policy  string           `xml:"crossmark_policy"`
+	Updates []crossrefUpdate `xml:"updates>update"`
+}
+
+type crossrefUpdate struct {
+	Type string `xml:"type,attr"`
+	Date string `xml:"date,attr"` // publication date of the correction
+	DOI  string `xml:",chardata"`
+}
+
+// crossrefCitationList is left out for articles without references, the
+// schema wants at least one citation in it
+type crossrefCitationList struct {
+	Citations []crossrefCitation `xml:"citation"`
+}
+
+// crossrefCitation is one reference of an article
+type crossrefCitation struct {
+	Key          string `xml:"key,attr"`
+	JournalTitle string `xml:"journal_title,omitempty"`
+	Author       string `xml:"author,omitempty"`
+	Volume       string `xml:"volume,omitempty"`
+	Issue        string `xml:"issue,omitempty"`
+	FirstPage    string `xml:"first_page,omitempty"`
+	Year         string `xml:"cYear,omitempty"`
+	DOI          string `xml:"doi,omitempty"`
+	VolumeTitle  string `xml:"volume_title,omitempty"`
+	ArticleTitle string `xml:"article_title,omitempty"`
+	Unstructured string `xml:"unstructured_citation,omitempty"`
+}
+
+// crossrefPagesRegex splits "121-128" or "121–128"; a single page has no last page
+var crossrefPagesRegex = regexp.MustCompile(`^\s*([^\s\-–—]+)\s*(?:[-–—]\s*(\S+))?\s*$`)
+
+// buildCrossrefDeposit reads the articles and doi sheets of a converted workbook.
+// All problems are collected into one error, the deposit is only built without them.
+func buildCrossrefDeposit(f *excelize.File, cfg crossrefConfig, now time.Time) (*crossrefBatch, error) {
+	rows, err := f.GetRows("articles")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read articles sheet: %w", err)
+	}
+	if len(rows) < 2 {
+		return nil, fmt.Errorf("articles sheet has no articles")
+	}
+	col := map[string]int{}
+	for i, h := range rows[0] {
+		col[strings.TrimSpace(h)] = i
+	}
+	for _, required := range []string{"pubdate", "articles.volume", "articles.issue", "articles.title", "articles.DOI"} {
+		if _, ok := col[required]; !ok {
+			return nil, fmt.Errorf("articles sheet has no %s column", required)
+		}
+	}
+	cell := func(row []string, name string) string {
+		if i, ok := col[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	// Article pages on kmkjournals.com, in the same rows as the DOIs
+	links := map[string]string{}
+	doiRows, _ := f.GetRows("doi")
+	for _, row := range doiRows {
+		if len(row) >= 2 {
+			links[normalizeDOI(row[1])] = strings.TrimSpace(row[0])
+		}
+	}
+
+	citations, err := crossrefCitations(f)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	if cfg.Depositor == "" || cfg.Email == "" {
+		problems = append(problems, "CROSSREF_DEPOSITOR and CROSSREF_EMAIL must be set")
+	}
+
+	first := rows[1]
+	parsed, err := parseJournalDOI(normalizeDOI(cell(first, "articles.DOI")))
+	if err != nil {
+		return nil, fmt.Errorf("cannot tell the journal from the first article: %w", err)
+	}
+	pubdate, err := time.Parse("02.01.2006", cell(first, "pubdate"))
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("publication date %q is not DD.MM.YYYY", cell(first, "pubdate")))
+	}
+	date := crossrefDate{MediaType: "online", Month: pubdate.Format("01"), Day: pubdate.Format("02"), Year: pubdate.Format("2006")}
+
+	batch := &crossrefBatch{
+		Version:        crossrefSchemaVersion,
+		Xmlns:          "http://www.crossref.org/schema/" + crossrefSchemaVersion,
+		XmlnsXSI:       "http://www.w3.org/2001/XMLSchema-instance",
+		XmlnsJATS:      "http://www.ncbi.nlm.nih.gov/JATS1",
+		SchemaLocation: "http://www.crossref.org/schema/" + crossrefSchemaVersion + " https://www.crossref.org/schemas/crossref" + crossrefSchemaVersion + ".xsd",
+		Head: crossrefHead{
+			BatchID:       fmt.Sprintf("%s-%s-%s-%s", parsed.Journal.Code, cell(first, "articles.volume"), cell(first, "articles.issue"), now.Format("20060102150405")),
+			Timestamp:     now.Format("20060102150405"),
+			DepositorName: cfg.Depositor,
+			Email:         cfg.Email,
+			Registrant:    cfg.Depositor,
+		},
+	}
+	batch.Journal.Metadata.Language = "en"
+	batch.Journal.Metadata.FullTitle = parsed.Journal.Name
+	batch.Journal.Issue = crossrefIssue{
+		PublicationDate: date,
+		Volume:          cell(first, "articles.volume"),
+		Issue:           cell(first, "articles.issue"),
+	}
+
+	for r, row := range rows[1:] {
+		artNum := r + 1
+		doi := normalizeDOI(cell(row, "articles.DOI"))
+		if doi == "" {
+			problems = append(problems, fmt.Sprintf("article %d has no DOI", artNum))
+			continue
+		}
+		art := crossrefArticle{
+			PublicationType: "full_text",
+			Title:           cell(row, "articles.title"),
+			PublicationDate: date,
+			DOI:             doi,
+			Resource:        links[doi],
+			CitationList:    citations[doi],
+		}
+		if art.Resource == "" {
+			problems = append(problems, fmt.Sprintf("article %d (%s) has no page link on the doi sheet", artNum, doi))
+		}
+		if ru := cell(row, "articles.title_ru"); ru != "" {
+			art.OriginalTitle = &crossrefOriginalTitle{Language: "ru", Title: ru}
+		}
+		for _, abstract := range []struct{ lang, column string }{{"en", "articles.summary"}, {"ru", "articles.summary_ru"}} {
+			if a := crossrefAbstractOf(abstract.lang, cell(row, abstract.column)); a != nil {
+				art.Abstracts = append(art.Abstracts, *a)
+			}
+		}
+		authors, _ := splitReferenceAuthors(cell(row, "articles.authors"))
+		for i, a := range authors {
+			sequence := "additional"
+			if i == 0 {
+				sequence = "first"
+			}
+			art.Contributors = append(art.Contributors, crossrefPerson{Sequence: sequence, Role: "author", GivenName: a.Initials, Surname: a.Surname})
+		}
+		if m := crossrefPagesRegex.FindStringSubmatch(cell(row, "articles.pages")); m != nil {
+			art.Pages = &crossrefPages{First: m[1], Last: m[2]}
+		}
+
+		if cell(row, "articles.type") == ArticleErratum.String() {
+			update := ArticleUpdate{
+				DOI:      normalizeDOI(cell(row, "articles.updates_doi")),
+				Type:     cell(row, "articles.updates_type"),
+				Citation: cell(row, "articles.updates_citation"),
+			}
+			if update.Type == "" {
+				update.Type = "correction"
+			}
+			switch {
+			case update.DOI == "":
+				problems = append(problems, fmt.Sprintf("correction %s: fill in articles.updates_doi for %s", doi, update.target()))
+			case cfg.CrossmarkPolicy == "":
+				problems = append(problems, fmt.Sprintf("correction %s: CROSSREF_CROSSMARK_POLICY must be set to deposit its update relation", doi))
+			default:
+				art.Crossmark = &crossrefCrossmark{
+					Version: "1",
+					Policy:  cfg.CrossmarkPolicy,
+					Updates: []crossrefUpdate{{Type: update.Type, Date: pubdate.Format("2006-01-02"), DOI: update.DOI}},
+				}
+			}
+		}
+		batch.Journal.Articles = append(batch.Journal.Articles, art)
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("cannot export to Crossref: %s", strings.Join(problems, "; "))
+	}
+	return batch, nil
+}
+
+// crossrefAbstractOf splits a summary cell into paragraphs; an empty cell gives no abstract
+func crossrefAbstractOf(lang, summary string) *crossrefAbstract {
+	var paragraphs []string
+	for _, line := range strings.Split(summary, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			paragraphs = append(paragraphs, line)
+		}
+	}
+	if len(paragraphs) == 0 {
+		return nil
+	}
+	return &crossrefAbstract{Language: lang, Paragraphs: paragraphs}
+}
+
+// crossrefCitations reads the References sheet into citation lists by article
+// DOI. Workbooks without the sheet give no citations.
+func crossrefCitations(f *excelize.File) (map[string]*crossrefCitationList, error) {
+	citations := map[string]*crossrefCitationList{}
+	if i, _ := f.GetSheetIndex("References"); i < 0 {
+		return citations, nil
+	}
+	rows, err := f.GetRows("References")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read References sheet: %w", err)
+	}
+	if len(rows) == 0 {
+		return citations, nil
+	}
+	col := map[string]int{}
+	for i, h := range rows[0] {
+		col[strings.TrimSpace(h)] = i
+	}
+	cell := func(row []string, name string) string {
+		if i, ok := col[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	firstAuthors, err := crossrefFirstAuthors(f)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, row := range rows[1:] {
+		artDOI := normalizeDOI(cell(row, "ref.art_doi"))
+		full := cell(row, "ref.full")
+		if artDOI == "" || full == "" {
+			continue
+		}
+		list := citations[artDOI]
+		if list == nil {
+			list = &crossrefCitationList{}
+			citations[artDOI] = list
+		}
+		citation := crossrefCitation{
+			Key:    fmt.Sprintf("ref%d", len(list.Citations)+1),
+			Author: firstAuthors[i+2],
+			Year:   citationYearRegex.FindString(cell(row, "ref.year")),
+			DOI:    normalizeDOI(cell(row, "ref.doi")),
+		}
+		title := strings.TrimRight(cell(row, "ref.title"), ". ")
+		switch cell(row, "ref.type") {
+		case "article":
+			citation.JournalTitle = cell(row, "ref.container")
+			citation.ArticleTitle = title
+			citation.Volume = cell(row, "ref.volume")
+			citation.Issue = cell(row, "ref.issue")
+			if m := crossrefPagesRegex.FindStringSubmatch(cell(row, "ref.pages")); m != nil {
+				citation.FirstPage = m[1]
+			}
+		case "chapter":
+			citation.VolumeTitle = cell(row, "ref.container")
+			citation.ArticleTitle = title
+		case "book":
+			citation.VolumeTitle = title
+		}
+		if citation.DOI == "" {
+			citation.DOI = normalizeDOI(cell(row, "ref.suggested_doi"))
+		}
+		if citation.DOI == "" {
+			citation.Unstructured = full
+		}
+		list.Citations = append(list.Citations, citation)
+	}
+	return citations, nil
+}
+
+// crossrefFirstAuthors reads the first author's surname of every reference from
+// the reference-authors sheet, by the reference's row on the References sheet
+func crossrefFirstAuthors(f *excelize.File) (map[int]string, error) {
+	authors := map[int]string{}
+	if i, _ := f.GetSheetIndex("reference-authors"); i < 0 {
+		return authors, nil
+	}
+	rows, err := f.GetRows("reference-authors")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reference-authors sheet: %w", err)
+	}
+	for _, row := range rows {
+		if len(row) < 4 || strings.TrimSpace(row[2]) != "1" {
+			continue
+		}
+		if refRow, err := strconv.Atoi(strings.TrimSpace(row[1])); err == nil {
+			authors[refRow] = strings.TrimSpace(row[3])
+		}
+	}
+	return authors, nil
+}
+
+// writeCrossrefDeposit writes the deposit as indented XML
+func writeCrossrefDeposit(w io.Writer, batch *crossrefBatch) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(batch); err != nil {
+		return fmt.Errorf("failed to encode deposit: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// runCrossref handles `server crossref`; returns the exit code
+func runCrossref(args []string) int {
+	fs := flag.NewFlagSet("crossref", flag.ContinueOnError)
+	out := fs.String("o", "deposit.xml", "output file")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Println("Usage: crossref [-o deposit.xml] <workbook.xlsx>")
+		return 2
+	}
+
+	f, err := excelize.OpenFile(fs.Arg(0))
+	if err != nil {
+		fmt.Printf("❌ failed to open %s: %v\n", fs.Arg(0), err)
+		return 1
+	}
+	defer f.Close()
+
+	batch, err := buildCrossrefDeposit(f, crossrefConfigFromEnv(), time.Now())
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+	file, err := os.Create(*out)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+	defer file.Close()
+	if err := writeCrossrefDeposit(file, batch); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+
+	updates := 0
+	for _, a := range batch.Journal.Articles {
+		if a.Crossmark != nil {
+			updates++
+		}
+	}
+	fmt.Printf("✓ Crossref deposit saved: %s (%d articles, %d corrections)\n", *out, len(batch.Journal.Articles), updates)
+	return 0
+}
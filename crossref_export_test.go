@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// fixtureExcel opens the expected workbook of an end-to-end fixture as an Excel file
+func fixtureExcel(t *testing.T, name string) *excelize.File {
+	t.Helper()
+	data, err := os.ReadFile("testdata/e2e/" + name + "/expected.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wb fixtureWorkbook
+	if err := json.Unmarshal(data, &wb); err != nil {
+		t.Fatal(err)
+	}
+	f := excelize.NewFile()
+	t.Cleanup(func() { f.Close() })
+	for sheet, rows := range wb {
+		f.NewSheet(sheet)
+		for r, row := range rows {
+			values := make([]any, len(row))
+			for i, v := range row {
+				values[i] = v
+			}
+			setRow(f, sheet, r+1, values...)
+		}
+	}
+	return f
+}
+
+var crossrefTestConfig = crossrefConfig{Depositor: "KMK Scientific Press", Email: "editor@example.org", CrossmarkPolicy: "10.15298/crossmark-policy"}
+
+func TestCrossrefDepositUpdates(t *testing.T) {
+	f := fixtureExcel(t, "eej_24_3")
+	batch, err := buildCrossrefDeposit(f, crossrefTestConfig, time.Date(2025, 6, 21, 10, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := writeCrossrefDeposit(&buf, batch); err != nil {
+		t.Fatal(err)
+	}
+	xml := buf.String()
+
+	for _, want := range []string{
+		`<doi_batch version="5.3.1" xmlns="http://www.crossref.org/schema/5.3.1"`,
+		`<doi_batch_id>EEJ-24-3-20250621100000</doi_batch_id>`,
+		`<full_title>Euroasian Entomological Journal</full_title>`,
+		`<person_name sequence="first" contributor_role="author">`,
+		`<surname>Ivanova</surname>`,
+		`<first_page>121</first_page>`,
+		`<resource>https://kmkjournals.com/journals/EEJ/paper_EEJ_24_3_1</resource>`,
+		`<crossmark_policy>10.15298/crossmark-policy</crossmark_policy>`,
+		`<update type="corrigendum" date="2025-06-20">10.15298/euroasentj.22.04.07</update>`,
+	} {
+		if !strings.Contains(xml, want) {
+			t.Errorf("deposit has no %s", want)
+		}
+	}
+	if n := strings.Count(xml, "<crossmark>"); n != 1 {
+		t.Errorf("%d crossmark sections, want one for the corrigendum", n)
+	}
+	if n := strings.Count(xml, "<journal_article "); n != 4 {
+		t.Errorf("%d articles, want 4", n)
+	}
+}
+
+// depositXML builds the deposit of a fixture workbook, writes it and reads it back
+func depositXML(t *testing.T, f *excelize.File) (string, crossrefBatch) {
+	t.Helper()
+	batch, err := buildCrossrefDeposit(f, crossrefTestConfig, time.Date(2025, 6, 21, 10, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := writeCrossrefDeposit(&buf, batch); err != nil {
+		t.Fatal(err)
+	}
+	var parsed crossrefBatch
+	if err := xml.Unmarshal(buf.Bytes(), &parsed); err != nil {
+		t.Fatalf("deposit is not well-formed: %v", err)
+	}
+	return buf.String(), parsed
+}
+
+func TestCrossrefDepositCitations(t *testing.T) {
+	f := fixtureExcel(t, "eej_24_3")
+	// a DOI matched for the first reference and kept by the editor
+	f.SetCellValue("References", "U2", "10.1098/rspb.2002.2218")
+	out, batch := depositXML(t, f)
+
+	articles := batch.Journal.Articles
+	if len(articles) != 4 {
+		t.Fatalf("%d articles, want 4", len(articles))
+	}
+	if articles[0].CitationList == nil {
+		t.Fatal("article 1 has no citation_list")
+	}
+	citations := articles[0].CitationList.Citations
+	if len(citations) != 6 {
+		t.Fatalf("article 1 has %d citations, want its 6 references", len(citations))
+	}
+	for i, c := range citations {
+		if want := fmt.Sprintf("ref%d", i+1); c.Key != want {
+			t.Errorf("citation %d key %q, want %q", i+1, c.Key, want)
+		}
+		if (c.DOI == "") == (c.Unstructured == "") {
+			t.Errorf("citation %s: doi %q, unstructured %q; want exactly one", c.Key, c.DOI, c.Unstructured)
+		}
+	}
+	if citations[0].DOI != "10.1098/rspb.2002.2218" {
+		t.Errorf("ref1 doi %q, want the matched DOI", citations[0].DOI)
+	}
+	if citations[5].DOI != "10.15468/dl.abc123" {
+		t.Errorf("ref6 doi %q, want the DOI found in the reference", citations[5].DOI)
+	}
+	if !strings.HasPrefix(citations[3].Unstructured, "Шарова И.Х. 1981.") {
+		t.Errorf("ref4 unstructured %q, want the reference text", citations[3].Unstructured)
+	}
+	if articles[2].CitationList != nil || strings.Count(out, "<citation_list>") != 2 {
+		t.Errorf("%d citation lists, want none for the obituary and the corrigendum", strings.Count(out, "<citation_list>"))
+	}
+}
+
+func TestCrossrefCitationFields(t *testing.T) {
+	_, batch := depositXML(t, fixtureExcel(t, "eej_24_3"))
+	got := batch.Journal.Articles[0].CitationList.Citations
+	want := []crossrefCitation{
+		{Key: "ref1", JournalTitle: "Proceedings of the Royal Society of London. Series B", Author: "Hebert",
+			Volume: "270", Issue: "1512", FirstPage: "313", Year: "2003",
+			ArticleTitle: "Biological identifications through DNA barcodes"},
+		{Key: "ref2", Author: "Kryzhanovskij", Year: "1983", VolumeTitle: "[The ground beetles of the USSR. Vol.1]"},
+		{Key: "ref3", Author: "Erwin", Year: "1985",
+			VolumeTitle:  "Taxonomy, phylogeny and zoogeography of beetles and ants",
+			ArticleTitle: "The taxon pulse: a general pattern of lineage radiation and extinction among carabid beetles"},
+		{Key: "ref4", Author: "Шарова", Year: "1981", VolumeTitle: "Жизненные формы жужелиц"},
+		{Key: "ref5", JournalTitle: "Евразиатский энтомол. журнал", Author: "Петров",
+			Volume: "9", Issue: "1", FirstPage: "10", Year: "2010", ArticleTitle: "Жужелицы Кулундинской степи"},
+		{Key: "ref6", Author: "GBIF.org", Year: "2024", DOI: "10.15468/dl.abc123"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("%d citations, want %d", len(got), len(want))
+	}
+	for i := range want {
+		got[i].Unstructured = "" // checked in TestCrossrefDepositCitations
+		if got[i] != want[i] {
+			t.Errorf("citation %d:\n got %+v\nwant %+v", i+1, got[i], want[i])
+		}
+	}
+}
+
+func TestCrossrefDepositAbstracts(t *testing.T) {
+	f := fixtureExcel(t, "eej_24_3")
+	f.SetCellValue("articles", "J3", "First paragraph.\n\nSecond paragraph.")
+	out, _ := depositXML(t, f)
+
+	if !strings.Contains(out, `xmlns:jats="http://www.ncbi.nlm.nih.gov/JATS1"`) {
+		t.Error("deposit does not declare the jats namespace")
+	}
+	// the abstracts of the second article, in order, between its contributors and its date
+	art := strings.Split(out, "<journal_article ")[2]
+	art = art[:strings.Index(art, "<publication_date")]
+	var got []string
+	for _, line := range strings.Split(art, "\n") {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, "<jats:") {
+			got = append(got, line)
+		}
+	}
+	want := []string{
+		`<jats:abstract xml:lang="en">`,
+		`<jats:p>First paragraph.</jats:p>`,
+		`<jats:p>Second paragraph.</jats:p>`,
+		`<jats:abstract xml:lang="ru">`,
+		`<jats:p>Из Джунгарского Алатау описан Amara (Curtonotus) exempla sp.n.</jats:p>`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("article 2 abstracts:\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if n := strings.Count(out, "<jats:abstract "); n != 4 {
+		t.Errorf("%d abstracts, want English and Russian for both research articles", n)
+	}
+}
+
+func TestCrossrefDepositProblems(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    crossrefConfig
+		change func(f *excelize.File)
+		want   string
+	}{
+		{"no crossmark policy", crossrefConfig{Depositor: "KMK", Email: "e@example.org"}, nil,
+			"CROSSREF_CROSSMARK_POLICY must be set"},
+		{"no depositor", crossrefConfig{CrossmarkPolicy: "10.15298/p"}, nil,
+			"CROSSREF_DEPOSITOR and CROSSREF_EMAIL must be set"},
+		{"correction without DOI", crossrefTestConfig, func(f *excelize.File) { f.SetCellValue("articles", "V5", "") },
+			"fill in articles.updates_doi for \"Ivanova A.B. 2023. Ground beetles"},
+		{"no page link", crossrefTestConfig, func(f *excelize.File) { f.SetCellValue("doi", "A2", "") },
+			"article 2 (10.15298/euroasentj.24.03.02) has no page link"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := fixtureExcel(t, "eej_24_3")
+			if tt.change != nil {
+				tt.change(f)
+			}
+			_, err := buildCrossrefDeposit(f, tt.cfg, time.Now())
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+/*
+CONVERSION JOBS
+
+A conversion can take minutes (the issue page is scraped, references may be
+matched against Crossref), longer than nginx waits for the response. An upload
+therefore only creates a job:
+
+	POST /api/jobs               upload ("document" field), returns the job
+	GET  /api/jobs/:id           status, stage and progress of the job
+	GET  /api/jobs/:id/download  the Excel file once the job is done
+	GET  /api/jobs/:id/events    the conversion log, see conversion_log.go
+
+JOB_WORKERS conversions run at the same time (default 2), further uploads wait
+in the queue. A finished job and its file are kept for JOB_TTL (Go duration,
+default 1h) and then removed. POST /api/convert still converts within the
+request for scripts that use it.
+
+Conversions of the same journal never overlap: they share the numbering state
+file (see StateManager.Lock).
+*/
+
+const (
+	defaultJobWorkers = 2
+	defaultJobTTL     = time.Hour
+	jobQueueSize      = 64
+	// a job keeps at most this many log lines, the rest only goes to the server log
+	maxJobLogEntries = 5000
+)
+
+// errJobQueueFull is returned when too many uploads wait for a worker
+var errJobQueueFull = errors.New("too many conversions are waiting, try again later")
+
+// JobStatus is the state of a conversion job
+type JobStatus string
+
+const (
+	JobQueued  JobStatus = "queued"
+	JobRunning JobStatus = "running"
+	JobDone    JobStatus = "done"
+	JobFailed  JobStatus = "failed"
+)
+
+// ConversionStage is reported by processDocument as it goes
+type ConversionStage struct {
+	Name    string `json:"name"`    // extracting, parsing, scraping, matching or writing
+	Current int    `json:"current"` // article being parsed, matched or written, 0 for other stages
+	Total   int    `json:"total"`   // number of articles
+}
+
+// stageSpans are the parts of the progress bar given to each stage, in percent
+var stageSpans = map[string][2]int{
+	"extracting": {0, 5},
+	"parsing":    {5, 40},
+	"scraping":   {40, 45},
+	"matching":   {45, 70},
+	"writing":    {70, 100},
+}
+
+// percent is the overall progress with the stage's current article counted in,
+// so the last article of a stage reaches the end of its span
+func (s ConversionStage) percent() int {
+	span, ok := stageSpans[s.Name]
+	if !ok {
+		return 0
+	}
+	if s.Total == 0 {
+		return span[0]
+	}
+	return span[0] + (span[1]-span[0])*s.Current/s.Total
+}
+
+func (s ConversionStage) String() string {
+	if s.Total == 0 {
+		return s.Name
+	}
+	return fmt.Sprintf("%s article %d of %d", s.Name, s.Current, s.Total)
+}
+
+// Job is one uploaded document and its conversion
+type Job struct {
+	ID       string          `json:"id"`
+	Filename string          `json:"filename"`
+	Status   JobStatus       `json:"status"`
+	Stage    ConversionStage `json:"stage"`
+	Progress int             `json:"progress"` // percent
+	Error    string          `json:"error,omitempty"`
+	Created  time.Time       `json:"created"`
+	Expires  *time.Time      `json:"expires,omitempty"` // set when the job is finished
+
+	inputPath  string
+	outputPath string
+	log        []LogEntry
+	logChanged chan struct{} // closed and replaced when the log or status changes
+}
+
+// DownloadName is the name of the Excel file offered to the user
+func (j Job) DownloadName() string {
+	return workbookName(j.Filename)
+}
+
+// JobQueue runs conversions on a fixed pool of workers
+type JobQueue struct {
+	mu    sync.Mutex
+	jobs  map[string]*Job
+	queue chan *Job
+	ttl   time.Duration
+
+	convert func(inputPath, outputPath string, clog *ConversionLog) error // processDocument
+}
+
+// newJobQueue starts the workers and the cleanup of expired jobs
+// Workers and TTL come from JOB_WORKERS and JOB_TTL
+func newJobQueue() *JobQueue {
+	workers := defaultJobWorkers
+	if v := os.Getenv("JOB_WORKERS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			workers = n
+		} else {
+			log.Printf("⚠️  Invalid JOB_WORKERS %q, using %d\n", v, defaultJobWorkers)
+		}
+	}
+	ttl := defaultJobTTL
+	if v := os.Getenv("JOB_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			ttl = d
+		} else {
+			log.Printf("⚠️  Invalid JOB_TTL %q, using %s\n", v, defaultJobTTL)
+		}
+	}
+
+	q := startJobQueue(workers, ttl, processDocument)
+	log.Printf("🧵 Conversion workers: %d, results kept for %s\n", workers, ttl)
+	return q
+}
+
+// startJobQueue starts workers running convert and the cleanup of expired jobs
+func startJobQueue(workers int, ttl time.Duration, convert func(inputPath, outputPath string, clog *ConversionLog) error) *JobQueue {
+	q := &JobQueue{
+		jobs:    map[string]*Job{},
+		queue:   make(chan *Job, jobQueueSize),
+		ttl:     ttl,
+		convert: convert,
+	}
+	for i := 0; i < workers; i++ {
+		go q.work()
+	}
+	go q.cleanup()
+	return q
+}
+
+// Submit queues the conversion of an uploaded file
+func (q *JobQueue) Submit(filename, inputPath, outputPath string) (Job, error) {
+	job := &Job{
+		ID:         newJobID(),
+		Filename:   filename,
+		Status:     JobQueued,
+		Created:    time.Now(),
+		inputPath:  inputPath,
+		outputPath: outputPath,
+		logChanged: make(chan struct{}),
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	select {
+	case q.queue <- job:
+	default:
+		return Job{}, errJobQueueFull
+	}
+	q.jobs[job.ID] = job
+	return *job, nil
+}
+
+// Get returns a copy of the job
+func (q *JobQueue) Get(id string) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// Log returns the job's log entries from index from on and whether the job is finished.
+// Without new entries, changed is closed when there are some or the job finishes.
+func (q *JobQueue) Log(id string, from int) (entries []LogEntry, finished bool, changed <-chan struct{}, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[id]
+	if !ok {
+		return nil, false, nil, false
+	}
+	if from < len(job.log) {
+		entries = append(entries, job.log[from:]...)
+	}
+	return entries, job.Status == JobDone || job.Status == JobFailed, job.logChanged, true
+}
+
+func (q *JobQueue) update(job *Job, change func(*Job)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	change(job)
+}
+
+// notify wakes the job's event streams; called with q.mu held
+func (j *Job) notify() {
+	close(j.logChanged)
+	j.logChanged = make(chan struct{})
+}
+
+// appendLog adds a conversion log line to the job; past maxJobLogEntries
+// lines are dropped and a warning says so. Called with q.mu held.
+func (j *Job) appendLog(entry LogEntry) {
+	switch {
+	case len(j.log) < maxJobLogEntries:
+		j.log = append(j.log, entry)
+	case len(j.log) == maxJobLogEntries:
+		j.log = append(j.log, LogEntry{Time: entry.Time, Level: logWarning,
+			Message: fmt.Sprintf("⚠️  Log truncated after %d lines, the rest is in the server log", maxJobLogEntries)})
+	default:
+		return
+	}
+	j.notify()
+}
+
+func (q *JobQueue) work() {
+	for job := range q.queue {
+		q.run(job)
+	}
+}
+
+// run converts one document; a panic in the parser fails the job, not the server
+func (q *JobQueue) run(job *Job) {
+	q.update(job, func(j *Job) { j.Status = JobRunning })
+	log.Printf("⚙️  Processing job %s: %s\n", job.ID, job.Filename)
+
+	var err error
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("conversion crashed: %v", r)
+			}
+		}()
+		err = q.convert(job.inputPath, job.outputPath, &ConversionLog{
+			onStage: func(stage ConversionStage) {
+				q.update(job, func(j *Job) {
+					j.Stage = stage
+					j.Progress = stage.percent()
+				})
+			},
+			onEntry: func(entry LogEntry) {
+				q.update(job, func(j *Job) { j.appendLog(entry) })
+			},
+			unattended: true,
+		})
+	}()
+
+	if removeErr := os.Remove(job.inputPath); removeErr != nil {
+		log.Printf("⚠️  Failed to delete input file: %v\n", removeErr)
+	}
+
+	expires := time.Now().Add(q.ttl)
+	q.update(job, func(j *Job) {
+		j.Expires = &expires
+		defer j.notify()
+		if err != nil {
+			j.Status = JobFailed
+			j.Error = fmt.Sprintf("Failed to process document: %v", err)
+			j.log = append(j.log, LogEntry{Time: time.Now(), Level: logError, Message: "❌ " + j.Error})
+			return
+		}
+		j.Status = JobDone
+		j.Progress = 100
+	})
+	if err != nil {
+		log.Printf("❌ Job %s failed: %v\n", job.ID, err)
+		if removeErr := os.Remove(job.outputPath); removeErr != nil && !os.IsNotExist(removeErr) {
+			log.Printf("⚠️  Failed to delete output file after error: %v\n", removeErr)
+		}
+		return
+	}
+	log.Printf("✅ Job %s done: %s\n", job.ID, job.Filename)
+}
+
+// cleanup removes expired jobs every minute
+func (q *JobQueue) cleanup() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		q.expire(now)
+	}
+}
+
+// expire removes the jobs that expired by now and their results
+func (q *JobQueue) expire(now time.Time) {
+	var expired []*Job
+	q.mu.Lock()
+	for id, job := range q.jobs {
+		if job.Expires != nil && now.After(*job.Expires) {
+			expired = append(expired, job)
+			delete(q.jobs, id)
+		}
+	}
+	q.mu.Unlock()
+
+	for _, job := range expired {
+		if err := os.Remove(job.outputPath); err != nil && !os.IsNotExist(err) {
+			log.Printf("⚠️  Failed to delete output file: %v\n", err)
+		}
+		log.Printf("🗑️  Job %s expired: %s\n", job.ID, job.Filename)
+	}
+}
+
+// newJobID returns a random hex ID that cannot be guessed from other jobs
+func newJobID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand does not fail on supported platforms
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
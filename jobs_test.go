@@ -0,0 +1,337 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// useJobQueue makes q the queue behind the API handlers for the rest of the test
+// and returns a router with the API endpoints
+func useJobQueue(t *testing.T, q *JobQueue) *gin.Engine {
+	t.Helper()
+	prev := jobs
+	jobs = q
+	t.Cleanup(func() { jobs = prev })
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	apiRoutes(router)
+	return router
+}
+
+// waitForJob polls the queue until ready accepts the job
+func waitForJob(t *testing.T, q *JobQueue, id string, ready func(Job) bool) Job {
+	t.Helper()
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		job, ok := q.Get(id)
+		if !ok {
+			t.Fatalf("job %s disappeared", id)
+		}
+		if ready(job) {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s stuck: status %s, stage %s", id, job.Status, job.Stage)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func jobFinished(j Job) bool { return j.Status == JobDone || j.Status == JobFailed }
+
+// copyFile copies a fixture document, jobs delete their input
+func copyFile(t *testing.T, src, dst string) {
+	t.Helper()
+	data, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dst, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func getStatus(router *gin.Engine, path string) int {
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec.Code
+}
+
+func TestJobQueueFull(t *testing.T) {
+	q := startJobQueue(0, time.Hour, nil) // no workers: every job stays queued
+	for i := 0; i < jobQueueSize; i++ {
+		job, err := q.Submit("issue.docx", "in.docx", "out.xlsx")
+		if err != nil {
+			t.Fatalf("job %d: %v", i+1, err)
+		}
+		if job.Status != JobQueued {
+			t.Fatalf("job %d is %s, want queued", i+1, job.Status)
+		}
+	}
+	if _, err := q.Submit("issue.docx", "in.docx", "out.xlsx"); !errors.Is(err, errJobQueueFull) {
+		t.Fatalf("Submit to a full queue: %v, want errJobQueueFull", err)
+	}
+	if len(q.jobs) != jobQueueSize {
+		t.Errorf("%d jobs kept, want the rejected one dropped", len(q.jobs))
+	}
+}
+
+func TestJobProgressDownloadAndExpiry(t *testing.T) {
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "in.docx"), filepath.Join(dir, "out.xlsx")
+	if err := os.WriteFile(in, []byte("docx"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	release := make(chan struct{})
+	q := startJobQueue(1, time.Hour, func(inputPath, outputPath string, clog *ConversionLog) error {
+		clog.stage("parsing", 1, 2)
+		<-release
+		clog.stage("writing", 2, 2)
+		return os.WriteFile(outputPath, []byte("xlsx"), 0644)
+	})
+	router := useJobQueue(t, q)
+
+	job, err := q.Submit("issue.docx", in, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	running := waitForJob(t, q, job.ID, func(j Job) bool { return j.Stage.Name == "parsing" })
+	if running.Status != JobRunning || running.Progress != 22 || running.Expires != nil {
+		t.Errorf("while parsing: status %s, progress %d, expires %v; want running, 22, none",
+			running.Status, running.Progress, running.Expires)
+	}
+	if code := getStatus(router, "/api/jobs/"+job.ID+"/download"); code != http.StatusConflict {
+		t.Errorf("download while running: %d, want %d", code, http.StatusConflict)
+	}
+
+	close(release)
+	done := waitForJob(t, q, job.ID, jobFinished)
+	if done.Status != JobDone || done.Progress != 100 || done.Expires == nil {
+		t.Fatalf("finished job: status %s (%s), progress %d, expires %v", done.Status, done.Error, done.Progress, done.Expires)
+	}
+	if _, err := os.Stat(in); !os.IsNotExist(err) {
+		t.Errorf("input file kept after the conversion: %v", err)
+	}
+	if code := getStatus(router, "/api/jobs/"+job.ID+"/download"); code != http.StatusOK {
+		t.Errorf("download of a done job: %d, want %d", code, http.StatusOK)
+	}
+
+	q.expire(done.Expires.Add(-time.Second))
+	if _, ok := q.Get(job.ID); !ok {
+		t.Fatal("job removed before JOB_TTL")
+	}
+	q.expire(done.Expires.Add(time.Second))
+	if _, ok := q.Get(job.ID); ok {
+		t.Error("job kept after JOB_TTL")
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("result kept after JOB_TTL: %v", err)
+	}
+	if code := getStatus(router, "/api/jobs/"+job.ID); code != http.StatusNotFound {
+		t.Errorf("status of an expired job: %d, want %d", code, http.StatusNotFound)
+	}
+}
+
+func TestConversionStagePercent(t *testing.T) {
+	tests := []struct {
+		stage ConversionStage
+		want  int
+	}{
+		{ConversionStage{Name: "extracting"}, 0},
+		{ConversionStage{Name: "parsing", Current: 1, Total: 2}, 22},
+		{ConversionStage{Name: "parsing", Current: 2, Total: 2}, 40},
+		{ConversionStage{Name: "scraping"}, 40},
+		{ConversionStage{Name: "writing", Current: 3, Total: 3}, 100},
+		{ConversionStage{Name: "unknown", Current: 1, Total: 1}, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.stage.percent(); got != tt.want {
+			t.Errorf("%s: percent() = %d, want %d", tt.stage, got, tt.want)
+		}
+	}
+}
+
+func TestJobPanicFailsOnlyThatJob(t *testing.T) {
+	dir := t.TempDir()
+	q := startJobQueue(1, time.Hour, func(inputPath, outputPath string, clog *ConversionLog) error {
+		if strings.HasSuffix(inputPath, "bad.docx") {
+			panic("runtime error: index out of range [3] with length 0")
+		}
+		return os.WriteFile(outputPath, []byte("xlsx"), 0644)
+	})
+
+	var ids []string
+	for _, name := range []string{"bad.docx", "good.docx"} {
+		in := filepath.Join(dir, name)
+		if err := os.WriteFile(in, []byte("docx"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		job, err := q.Submit(name, in, in+".xlsx")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, job.ID)
+	}
+
+	bad := waitForJob(t, q, ids[0], jobFinished)
+	if bad.Status != JobFailed || !strings.Contains(bad.Error, "conversion crashed") {
+		t.Errorf("crashed job: status %s, error %q", bad.Status, bad.Error)
+	}
+	entries, finished, _, _ := q.Log(ids[0], 0)
+	if !finished || len(entries) == 0 || entries[len(entries)-1].Level != logError {
+		t.Errorf("crashed job log %v (finished %v), want an error entry last", entries, finished)
+	}
+	if good := waitForJob(t, q, ids[1], jobFinished); good.Status != JobDone {
+		t.Errorf("job after the crash: status %s (%s), want done on the same worker", good.Status, good.Error)
+	}
+}
+
+func TestJobConvertsFixture(t *testing.T) {
+	dir, err := filepath.Abs("testdata/e2e/eej_24_3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	docPath, _, serverURL := setupFixture(t, dir)
+	if err := os.MkdirAll("temp", 0755); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(useJobQueue(t, startJobQueue(1, time.Hour, processDocument)))
+	defer srv.Close()
+
+	// Upload as the frontend does
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("document", "EEJ_24_3.docx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := os.ReadFile(docPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(doc)
+	form.Close()
+	resp, err := http.Post(srv.URL+"/api/jobs", form.FormDataContentType(), &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var job Job
+	json.NewDecoder(resp.Body).Decode(&job)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted || job.ID == "" {
+		t.Fatalf("POST /api/jobs: %s, job %+v", resp.Status, job)
+	}
+
+	// Poll the status until the job is finished
+	deadline := time.Now().Add(30 * time.Second)
+	for job.Status != JobDone {
+		if job.Status == JobFailed || time.Now().After(deadline) {
+			t.Fatalf("job %s: status %s, stage %s, error %q", job.ID, job.Status, job.Stage, job.Error)
+		}
+		time.Sleep(20 * time.Millisecond)
+		resp, err := http.Get(srv.URL + "/api/jobs/" + job.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		json.NewDecoder(resp.Body).Decode(&job)
+		resp.Body.Close()
+	}
+
+	entries, _, _, _ := jobs.Log(job.ID, 0)
+	registryLogged := false
+	for _, entry := range entries {
+		registryLogged = registryLogged || strings.Contains(entry.Message, "Organization registry")
+	}
+	if !registryLogged {
+		t.Error("organization registry status missing from the job log")
+	}
+
+	resp, err = http.Get(srv.URL + "/api/jobs/" + job.ID + "/download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("download: %s", resp.Status)
+	}
+	if cd := resp.Header.Get("Content-Disposition"); !strings.Contains(cd, "EEJ_24_3.xlsx") {
+		t.Errorf("Content-Disposition %q, want the upload's name with .xlsx", cd)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("download.xlsx", data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readFixtureWorkbook("download.xlsx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got.replaceServerURL(serverURL)
+	compareWorkbooks(t, got, readExpectedWorkbook(t, dir))
+}
+
+func TestJobFailures(t *testing.T) {
+	dir, err := filepath.Abs("testdata/e2e/eej_24_3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T, work string)
+		want    string
+	}{
+		{
+			name: "unconfigured state file",
+			prepare: func(t *testing.T, work string) {
+				state := filepath.Join(work, "state", "EEJ_state.yaml")
+				if err := os.WriteFile(state, []byte("journal_code: EEJ\ncurrent_counter: 0\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: "EEJ_state.yaml has no starting point",
+		},
+		{
+			name: "issue page not found",
+			prepare: func(t *testing.T, work string) {
+				srv := httptest.NewServer(http.NotFoundHandler())
+				t.Cleanup(srv.Close)
+				kmkjournalsBaseURL = srv.URL
+			},
+			want: "failed to get the issue page",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docPath, work, _ := setupFixture(t, dir)
+			tt.prepare(t, work)
+			in := filepath.Join(work, "issue.docx")
+			copyFile(t, docPath, in)
+
+			q := startJobQueue(1, time.Hour, processDocument)
+			job, err := q.Submit("issue.docx", in, filepath.Join(work, "output.xlsx"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			job = waitForJob(t, q, job.ID, jobFinished)
+			if job.Status != JobFailed || !strings.Contains(job.Error, tt.want) {
+				t.Errorf("status %s, error %q, want failed with %q", job.Status, job.Error, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import "strings"
+
+// journalDOIPrefix is the Crossref prefix shared by all our journals
+const journalDOIPrefix = "10.15298"
+
+// JournalEntry describes one of the journals handled by the converter
+type JournalEntry struct {
+	Code       string // short code used for state files, e.g. "EEJ"
+	DOICode    string // journal segment of the DOI, e.g. "euroasentj"
+	CatalogKey string // kmkjournals.com catalog folder, e.g. "Inv_Zool"
+	Name       string // full journal title
+	// Aliases are other names the journal is cited under: Russian title, abbreviations
+	Aliases []string
+	// Pages is how the articles sheet shows page ranges; page_format in the state file overrides it
+	Pages PageFormat
+	// CitationStyle is the reference style profile: "kmk", "apa", "gost" or "auto";
+	// empty means the default profile. citation_style in the state file overrides it.
+	CitationStyle string
+}
+
+// journalRegistry lists the journals whose DOIs follow
+// 10.15298/<DOICode>.<volume>.<issue>.<article>
+var journalRegistry = []JournalEntry{
+	{Code: "EEJ", DOICode: "euroasentj", CatalogKey: "EEJ", Name: "Euroasian Entomological Journal", Pages: defaultPageFormat,
+		Aliases: []string{"Euroasian Entomol. J.", "Евразиатский энтомологический журнал", "Евразиатский энтомол. журнал"}},
+	{Code: "REJ", DOICode: "rusentj", CatalogKey: "REJ", Name: "Russian Entomological Journal", Pages: defaultPageFormat,
+		Aliases: []string{"Russian Entomol. J.", "Russ. Entomol. J.", "Русский энтомологический журнал"}},
+	{Code: "IZ", DOICode: "invertzool", CatalogKey: "Inv_Zool", Name: "Invertebrate Zoology", Pages: defaultPageFormat,
+		Aliases: []string{"Invert. Zool.", "Зоология беспозвоночных", "Зоол. беспозв."}},
+	{Code: "AS", DOICode: "arthsel", CatalogKey: "AS", Name: "Arthropoda Selecta", Pages: defaultPageFormat,
+		Aliases: []string{"Arthropoda Sel."}},
+}
+
+// journalByCode finds a journal by its short code
+func journalByCode(code string) (JournalEntry, bool) {
+	for _, j := range journalRegistry {
+		if j.Code == code {
+			return j, true
+		}
+	}
+	return JournalEntry{}, false
+}
+
+// configuredJournal is the registry entry of a journal with the settings of its
+// state file applied, so they can be changed without a new build
+func configuredJournal(code string, state *JournalState) JournalEntry {
+	journal, ok := journalByCode(code)
+	if !ok {
+		journal = JournalEntry{Code: code, Name: state.JournalName, Pages: defaultPageFormat}
+	}
+	if style := strings.ToLower(strings.TrimSpace(state.CitationStyle)); style != "" {
+		journal.CitationStyle = style
+	}
+	if state.PageFormat != nil {
+		journal.Pages = *state.PageFormat
+		if journal.Pages.Separator == "" {
+			journal.Pages.Separator = defaultPageFormat.Separator
+		}
+	}
+	return journal
+}
+
+// journalByDOICode finds a journal by the journal segment of its DOIs
+func journalByDOICode(doiCode string) (JournalEntry, bool) {
+	for _, j := range journalRegistry {
+		if j.DOICode == doiCode {
+			return j, true
+		}
+	}
+	return JournalEntry{}, false
+}
+
+// citedJournal finds which of our journals a reference cites: by a 10.15298/<DOICode>.* DOI,
+// else by the journal's name or an alias in the reference text
+func citedJournal(text, doi string) (JournalEntry, bool) {
+	if code, ok := strings.CutPrefix(normalizeDOI(doi), journalDOIPrefix+"/"); ok {
+		code, _, _ = strings.Cut(code, ".")
+		if j, ok := journalByDOICode(code); ok {
+			return j, true
+		}
+	}
+
+	text = strings.ToLower(strings.Join(strings.Fields(text), " "))
+	for _, j := range journalRegistry {
+		for _, name := range append([]string{j.Name}, j.Aliases...) {
+			if strings.Contains(text, strings.ToLower(name)) {
+				return j, true
+			}
+		}
+	}
+	return JournalEntry{}, false
+}
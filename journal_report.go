@@ -0,0 +1,196 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+/*
+JOURNAL CITATION REPORT
+
+The editorial board asks every year how often our four journals are cited in
+our own issues. A reference cites one of them when it has a 10.15298 DOI of the
+journal or names it (registry name or alias, see citedJournal).
+
+Every converted workbook gets a "journal-citations" sheet for its issue, and
+
+	server journal-report [-o journal-report.xlsx] <workbook.xlsx | directory>...
+
+combines converted issues into a report with two sheets:
+
+	per-issue  citations to each journal from every issue
+	per-year   citations to each journal by publication year of the citing issue,
+	           with the citations to items of the two previous years
+	           (the numerator of a two-year impact factor)
+
+The citing year is taken from the pubdate column of the articles sheet.
+*/
+
+// journalCitationCount counts the citations to one of our journals
+type journalCitationCount struct {
+	Citations int
+	Recent    int // to items published in the two years before the citing year
+}
+
+// journalCitationCounts is keyed by journal code
+type journalCitationCounts map[string]*journalCitationCount
+
+// Add counts one citation; citedYear and citingYear may be empty or malformed
+func (c journalCitationCounts) Add(journal, citedYear, citingYear string) {
+	count := c[journal]
+	if count == nil {
+		count = &journalCitationCount{}
+		c[journal] = count
+	}
+	count.Citations++
+	cited, err1 := strconv.Atoi(citationYearRegex.FindString(citedYear))
+	citing, err2 := strconv.Atoi(citingYear)
+	if err1 == nil && err2 == nil && (citing-cited == 1 || citing-cited == 2) {
+		count.Recent++
+	}
+}
+
+func (c journalCitationCounts) get(journal string) journalCitationCount {
+	if count := c[journal]; count != nil {
+		return *count
+	}
+	return journalCitationCount{}
+}
+
+// writeJournalCitationSheet writes one row per journal of the registry
+func writeJournalCitationSheet(f *excelize.File, sheet string, counts journalCitationCounts) {
+	f.NewSheet(sheet)
+	setRow(f, sheet, 1, "journal.code", "journal.name", "journal.citations", "journal.citations_prev_2_years")
+	for i, j := range journalRegistry {
+		count := counts.get(j.Code)
+		setRow(f, sheet, i+2, j.Code, j.Name, count.Citations, count.Recent)
+	}
+}
+
+// exportJournalReport writes the per-issue and per-year sheets
+func exportJournalReport(idx *CitationIndex, path string) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	// Count per citing issue and per citing year
+	byIssue := map[*IssueCitations]journalCitationCounts{}
+	byYear := map[string]journalCitationCounts{}
+	for _, rec := range idx.Records {
+		if rec.Journal == "" {
+			continue
+		}
+		issue := idx.Issues[issueKeyOf(rec.ArtDOI)]
+		if byIssue[issue] == nil {
+			byIssue[issue] = journalCitationCounts{}
+		}
+		byIssue[issue].Add(rec.Journal, rec.Year, rec.CitingYear)
+
+		year := rec.CitingYear
+		if year == "" {
+			year = "unknown"
+		}
+		if byYear[year] == nil {
+			byYear[year] = journalCitationCounts{}
+		}
+		byYear[year].Add(rec.Journal, rec.Year, rec.CitingYear)
+	}
+
+	f.SetSheetName("Sheet1", "per-issue")
+	header := []any{"issue.journal", "issue.volume", "issue.issue", "issue.year", "issue.references"}
+	for _, j := range journalRegistry {
+		header = append(header, "issue.cites_"+strings.ToLower(j.Code))
+	}
+	setRow(f, "per-issue", 1, append(header, "issue.cites_total")...)
+	for i, is := range idx.SortedIssues() {
+		values := []any{is.Journal, is.Volume, is.Issue, is.Year, is.References}
+		if is.unknownArticle {
+			values[0], values[1], values[2] = "(other DOIs)", "", ""
+		}
+		total := 0
+		for _, j := range journalRegistry {
+			n := byIssue[is].get(j.Code).Citations
+			values = append(values, n)
+			total += n
+		}
+		setRow(f, "per-issue", i+2, append(values, total)...)
+	}
+
+	f.NewSheet("per-year")
+	setRow(f, "per-year", 1, "year.citing", "year.cited_journal", "year.cited_journal_name", "year.citations", "year.citations_prev_2_years")
+	years := make([]string, 0, len(byYear))
+	for year := range byYear {
+		years = append(years, year)
+	}
+	sort.Strings(years)
+	row := 2
+	for _, year := range years {
+		for _, j := range journalRegistry {
+			count := byYear[year].get(j.Code)
+			setRow(f, "per-year", row, year, j.Code, j.Name, count.Citations, count.Recent)
+			row++
+		}
+	}
+
+	if err := f.SaveAs(path); err != nil {
+		return fmt.Errorf("failed to save %s: %w", path, err)
+	}
+	return nil
+}
+
+// issueKeyOf is the CitationIndex.Issues key of an article DOI
+func issueKeyOf(artDOI string) string {
+	parsed, err := parseJournalDOI(artDOI)
+	if err != nil {
+		return "unknown"
+	}
+	return fmt.Sprintf("%s %d %d", parsed.Journal.Code, parsed.Volume, parsed.Issue)
+}
+
+// runJournalReport handles `server journal-report`; returns the exit code
+func runJournalReport(args []string) int {
+	fs := flag.NewFlagSet("journal-report", flag.ContinueOnError)
+	out := fs.String("o", "journal-report.xlsx", "output workbook")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fmt.Println("Usage: journal-report [-o journal-report.xlsx] <workbook.xlsx | directory>...")
+		return 2
+	}
+
+	paths, err := workbookPaths(fs.Args())
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+	idx := newCitationIndex()
+	for _, path := range paths {
+		if err := idx.LoadWorkbook(path, nil); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return 1
+		}
+	}
+
+	counts := journalCitationCounts{}
+	for _, rec := range idx.Records {
+		if rec.Journal != "" {
+			counts.Add(rec.Journal, rec.Year, rec.CitingYear)
+		}
+	}
+	fmt.Printf("📚 %d references from %d issues in %d workbooks\n", len(idx.Records), len(idx.Issues), idx.Workbooks)
+	for _, j := range journalRegistry {
+		fmt.Printf("   %-4s %-32s %d citations\n", j.Code, j.Name, counts.get(j.Code).Citations)
+	}
+
+	if err := exportJournalReport(idx, *out); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+	fmt.Printf("✓ Journal citation report saved: %s\n", *out)
+	return 0
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxReportedMarkerIssues limits how many marker problems go into the error message
+const maxReportedMarkerIssues = 5
+
+// markerRunRegex matches runs of angle brackets that look like reference markers;
+// "<<" and ">>" occur in ordinary text (quotes, "much greater than")
+var markerRunRegex = regexp.MustCompile(`<{3,}|>{3,}`)
+
+// markerSeverity tells whether a marker issue stops the conversion
+type markerSeverity int
+
+const (
+	markerError   markerSeverity = iota // the document cannot be split reliably
+	markerWarning                       // reported, conversion continues
+)
+
+// markerIssue is a problem with the <<< >>> reference markers
+type markerIssue struct {
+	severity markerSeverity
+	line     int    // 1-based line of the offending marker
+	message  string // what is wrong
+	context  string // text around the marker
+}
+
+func (mi markerIssue) String() string {
+	return fmt.Sprintf("line %d: %s (%q)", mi.line, mi.message, mi.context)
+}
+
+// lintMarkers checks <<< >>> reference markers before parsing:
+// balance, nesting, malformed markers, empty reference blocks and
+// text left after the last >>> (usually a missing marker on the last article).
+// Empty reference blocks are only warnings: obituaries, errata and editorials
+// often have no references.
+func lintMarkers(body string) []markerIssue {
+	var issues []markerIssue
+	openAt := -1 // offset of the currently open <<<, -1 if none
+	lastClose := -1
+
+	issueAt := func(offset int, message string) {
+		issues = append(issues, markerIssue{
+			severity: markerError,
+			line:     lineAt(body, offset),
+			message:  message,
+			context:  markerContext(body, offset),
+		})
+	}
+	warningAt := func(offset int, message string) {
+		issueAt(offset, message)
+		issues[len(issues)-1].severity = markerWarning
+	}
+
+	for _, loc := range markerRunRegex.FindAllStringIndex(body, -1) {
+		run := body[loc[0]:loc[1]]
+		if len(run) != 3 {
+			issueAt(loc[0], fmt.Sprintf("malformed marker %q, expected <<< or >>>", run))
+			continue
+		}
+
+		if run == "<<<" {
+			if openAt != -1 {
+				issueAt(loc[0], fmt.Sprintf("<<< opened while <<< from line %d is still open (missing >>>?)", lineAt(body, openAt)))
+			}
+			openAt = loc[0]
+			continue
+		}
+
+		// run == ">>>"
+		if openAt == -1 {
+			issueAt(loc[0], ">>> without matching <<<")
+			lastClose = loc[1] // the text before it is already reported
+			continue
+		}
+		if strings.TrimSpace(body[openAt+3:loc[0]]) == "" {
+			warningAt(openAt, "empty reference block")
+		}
+		openAt = -1
+		lastClose = loc[1]
+	}
+
+	if openAt != -1 {
+		issueAt(openAt, "<<< is never closed with >>>")
+	}
+
+	if lastClose != -1 {
+		if tail := body[lastClose:]; strings.TrimSpace(tail) != "" {
+			issueAt(firstTextOffset(body, lastClose, len(body)), "text after the last >>> (missing <<< >>> on the last article?)")
+		}
+	}
+
+	return issues
+}
+
+// markerContext returns the text of the line around offset, shortened for messages
+func markerContext(body string, offset int) string {
+	start := strings.LastIndexByte(body[:offset], '\n') + 1
+	end := len(body)
+	if i := strings.IndexByte(body[offset:], '\n'); i != -1 {
+		end = offset + i
+	}
+
+	const radius = 40
+	from := max(start, offset-radius)
+	to := min(end, offset+radius)
+	// do not cut a multi-byte character in half
+	for from > start && !utf8.RuneStart(body[from]) {
+		from--
+	}
+	for to < end && !utf8.RuneStart(body[to]) {
+		to++
+	}
+
+	context := strings.TrimSpace(body[from:to])
+	if from > start {
+		context = "…" + context
+	}
+	if to < end {
+		context += "…"
+	}
+	return context
+}
+
+// markerLintError formats marker issues into a single error for the user
+func markerLintError(issues []markerIssue) error {
+	var parts []string
+	for i, issue := range issues {
+		if i == maxReportedMarkerIssues {
+			parts = append(parts, fmt.Sprintf("and %d more", len(issues)-maxReportedMarkerIssues))
+			break
+		}
+		parts = append(parts, issue.String())
+	}
+	return fmt.Errorf("reference markers <<< >>> are broken, fix the document and try again: %s",
+		strings.Join(parts, "; "))
+}
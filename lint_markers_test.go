@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLintMarkers(t *testing.T) {
+	const header = "Ivanova A.B. 2025. Title // Euroasian Entomological Journal. Vol.24. No.3. P.1–8.\n"
+	tests := []struct {
+		name string
+		body string
+		want []string // "error line N: message fragment" or "warning line N: ..."
+	}{
+		{
+			name: "well-formed",
+			body: header + "<<<Ref one.\nRef two.>>>\n" + header + "<<<Ref three.>>>\n",
+		},
+		{
+			name: "harmless double brackets",
+			body: header + "Abstract. Males <<much>> larger, size ratio >> 2.\n<<<Ref one.>>>\n",
+		},
+		{
+			name: "unclosed block",
+			body: header + "<<<Ref one.\n" + header + "<<<Ref two.>>>\n",
+			want: []string{"error line 4: <<< opened while <<< from line 2"},
+		},
+		{
+			name: "never closed",
+			body: header + "<<<Ref one.\nRef two.\n",
+			want: []string{"error line 2: <<< is never closed"},
+		},
+		{
+			name: "close without open",
+			body: header + "Ref one.>>>\n",
+			want: []string{"error line 2: >>> without matching <<<"},
+		},
+		{
+			name: "nested block",
+			body: header + "<<<Ref one.\n<<<Ref two.>>>\nRef three.>>>\n",
+			want: []string{
+				"error line 3: <<< opened while <<< from line 2",
+				"error line 4: >>> without matching <<<",
+			},
+		},
+		{
+			name: "malformed run",
+			body: header + "<<<Ref one.>>>>\n",
+			want: []string{
+				`error line 2: malformed marker ">>>>"`,
+				"error line 2: <<< is never closed",
+			},
+		},
+		{
+			name: "empty block is a warning",
+			body: header + "<<<Ref one.>>>\nObituary\n" + header + "<<< >>>\n",
+			want: []string{"warning line 5: empty reference block"},
+		},
+		{
+			name: "text after the last block",
+			body: header + "<<<Ref one.>>>\n" + header + "Ref two.\n",
+			want: []string{"error line 3: text after the last >>>"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := lintMarkers(tt.body)
+			if len(issues) != len(tt.want) {
+				t.Fatalf("%d issues %v, want %d", len(issues), issues, len(tt.want))
+			}
+			for i, issue := range issues {
+				severity := "error"
+				if issue.severity == markerWarning {
+					severity = "warning"
+				}
+				got := severity + " " + issue.String()
+				if !strings.HasPrefix(got, tt.want[i]) {
+					t.Errorf("issue %d = %s, want %s…", i+1, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ package main
 
 import (
 	"fmt"
+	"math"
 	"os"
 	"os/exec"
 	"regexp"
@@ -18,36 +19,13 @@ import (
 	"github.com/xuri/excelize/v2"
 )
 
+// authSuffixRegex matches affiliation numbers and footnote stars after author names
+var authSuffixRegex = regexp.MustCompile(`\d+(,)?(\*)?`)
+
 func deleteSubstring(s string) string {
 	return ""
 }
 
-func formatPageNumbers(pages string) string {
-	if pages == "" {
-		return ""
-	}
-
-	// Extract the page range using a more comprehensive regex that handles all dash types
-	pagesRegex := regexp.MustCompile(`(\d+)[–-—](\d+)`)
-	matches := pagesRegex.FindStringSubmatch(pages)
-
-	if len(matches) == 3 {
-		startPage := matches[1]
-		endPage := matches[2]
-
-		// Convert to integers to handle leading zeros properly
-		start, err1 := strconv.Atoi(startPage)
-		end, err2 := strconv.Atoi(endPage)
-
-		if err1 == nil && err2 == nil {
-			// Format with leading zeros (3 digits)
-			return fmt.Sprintf("%03d-%03d", start, end)
-		}
-	}
-
-	return pages
-}
-
 func writeOutput(articles []string) {
 	sfs, err := os.OpenFile("output.txt", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
@@ -68,20 +46,15 @@ func writeOutput(articles []string) {
 	}
 }
 
-func printError(artNum int, message string) {
-	fmt.Printf("⚠️  [Article %d] ERROR: %s\n", artNum, message)
-}
-
-func printWarning(artNum int, field string, message string) {
-	fmt.Printf("⚠️  [Article %d] WARNING - %s: %s\n", artNum, field, message)
-}
-
-// processDocument converts a DOC/DOCX file to Excel format
+// processDocument converts a DOC/DOCX/ODT file to Excel format
+// Output and stages go to clog, which may be nil (see conversion_log.go)
 // Returns error if processing fails
-func processDocument(docPath, outputPath string) error {
+func processDocument(docPath, outputPath string, clog *ConversionLog) error {
 	if docPath == "" {
 		return fmt.Errorf("path to doc file is not provided")
 	}
+
+	clog.stage("extracting", 0, 0)
 	res, err := docconv.ConvertPath(docPath)
 	if err != nil {
 		return fmt.Errorf("failed to convert document: %w", err)
@@ -89,7 +62,7 @@ func processDocument(docPath, outputPath string) error {
 
 	// Fallback: if docconv returns empty body for .doc files, try catdoc/antiword directly
 	if len(res.Body) == 0 && strings.HasSuffix(strings.ToLower(docPath), ".doc") {
-		fmt.Println("Warning: docconv returned empty content, trying fallback converters...")
+		clog.Println("Warning: docconv returned empty content, trying fallback converters...")
 
 		// Try multiple converters in order of preference (catdoc for better encoding)
 		converters := []struct {
@@ -110,7 +83,7 @@ func processDocument(docPath, outputPath string) error {
 				output, convErr := cmd.Output()
 				if convErr == nil && len(output) > 0 {
 					res.Body = string(output)
-					fmt.Printf("✓ Successfully converted using %s (%d bytes)\n", conv.name, len(res.Body))
+					clog.Printf("✓ Successfully converted using %s (%d bytes)\n", conv.name, len(res.Body))
 					converted = true
 					break
 				}
@@ -125,56 +98,53 @@ func processDocument(docPath, outputPath string) error {
 			return fmt.Errorf("docconv returned empty content and no fallback converters found (tried: catdoc, wvText)")
 		}
 	}
-	yearRegex := regexp.MustCompile(`\d\d\d\d`)
 	numsRegex := regexp.MustCompile(`[[:alpha:].](\d)`)
-	pagesRegex := regexp.MustCompile(`(\d+)[–-—](\d+)`)
-	abstractRegex := regexp.MustCompile(`(?i)abstract[s.:]`)
-	kwRegex := regexp.MustCompile(`(?i)key\s*words[.:]`)
-	doiRegex := regexp.MustCompile(`(?i)\bdoi(?:\s|\.|:)\s?(\d[^\n]*)`)
-	authSuffxRegex := regexp.MustCompile(`\d+(,)?(\*)?`)
 	refSepRegex := regexp.MustCompile(`\r\n|\r|\n`)
 	mailSeps := [4]string{"E-mail", "Email", "email", "e-mail"}
 
-	artRefSep := regexp.MustCompile(`(?s)(.*?)<<<(.*?)>>>`)
-	matches := artRefSep.FindAllStringSubmatch(res.Body, -1)
+	body := normalizeLineEndings(res.Body)
+	segments, err := segmentArticles(body, clog)
+	if err != nil {
+		return fmt.Errorf("failed to split document into articles: %w", err)
+	}
 
 	// Collect parsed articles and references
 	var articles []string
 	var references []string
-	for _, match := range matches {
-		articles = append(articles, match[1])     // Article content before <<<
-		references = append(references, match[2]) // References between <<< >>>
+	for _, seg := range segments {
+		articles = append(articles, seg.text)           // Article header (before <<<)
+		references = append(references, seg.references) // References (between <<< >>>)
 	}
 
-	fmt.Printf("Articles found: %d\n", len(articles))
+	clog.Printf("Articles found: %d\n", len(articles))
+
+	// Affiliations are matched against the organization registry
+	orgs := loadOrgRegistry(clog)
 
 	articlesNormalized := make([]Article, len(articles))
 	for artIndex, art := range articles {
+		clog.stage("parsing", artIndex+1, len(articles))
 		normArt := Article{}
-		referencesArr := refSepRegex.Split(references[artIndex], -1)
-		normArt.references = referencesArr
-		// Extract abstract and keywords
-		var artAbstract, artKW string
-		if len(abstractRegex.Split(art, 2)) < 2 {
-			printError(artIndex+1, "ABSTRACT section not found in article text")
-			// Continue with empty abstract rather than failing
-			artAbstract = ""
-			artKW = ""
-		} else {
-			abstractAndKW := kwRegex.Split(abstractRegex.Split(art, 2)[1], 2)
-			if len(abstractAndKW) > 0 {
-				artAbstract = abstractRegex.ReplaceAllStringFunc(abstractAndKW[0], deleteSubstring)
-			}
-			if len(abstractAndKW) > 1 {
-				artKW = kwRegex.ReplaceAllStringFunc(abstractAndKW[1], deleteSubstring)
-			} else {
-				// Keywords might be missing, use empty string
-				artKW = ""
-				printWarning(artIndex+1, "KEYWORDS", "Keywords section not found, continuing with empty keywords")
+		// Item type from an @type marker or rubric heading; those lines are removed from the header
+		artType, art, typeSource, typeProblems := classifyArticle(art)
+		for _, msg := range typeProblems {
+			clog.printWarning(artIndex+1, "TYPE", msg)
+		}
+		normArt.articleType = artType
+		// Blank lines are not references (an obituary may have an empty <<< >>> block)
+		for _, ref := range refSepRegex.Split(references[artIndex], -1) {
+			if strings.TrimSpace(ref) != "" {
+				normArt.references = append(normArt.references, ref)
 			}
 		}
-		normArt.abstract = strings.TrimSpace(artAbstract)
-		normArt.keywords = strings.TrimSpace(artKW)
+		// Extract abstracts and keywords (English and Russian)
+		abstracts := extractAbstracts(art)
+		normArt.abstractEn = abstracts.abstractEn
+		normArt.keywordsEn = splitKeywords(abstracts.keywordsEn)
+		normArt.abstractRu = abstracts.abstractRu
+		normArt.keywordsRu = splitKeywords(abstracts.keywordsRu)
+		normArt.titleRu = abstracts.titleRu
+		normArt.authorsRu = abstracts.authorsRu
 		// Try different line ending formats
 		var artStrings []string
 
@@ -197,46 +167,100 @@ func processDocument(docPath, outputPath string) error {
 		}
 
 		writeOutput(artStrings)
-		// DOI LOOP
+		// DOI LOOP: the first DOI in the header wins, others are reported
+		// (a correction also carries the DOI of the corrected article, see below)
 		var doi string
+		var headerDOIs []string
 		for _, str := range artStrings {
-			if doiRegex.MatchString(str) {
-				doi = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(str, "doi"), ":"), "."))
+			if !doiLineRegex.MatchString(str) {
+				continue
 			}
+			found := normalizeDOI(str)
+			if found == "" || slices.Contains(headerDOIs, found) {
+				continue
+			}
+			headerDOIs = append(headerDOIs, found)
 		}
-		if doi == "" {
-			printWarning(artIndex+1, "DOI", "DOI not found in article")
+		if len(headerDOIs) > 0 {
+			doi = headerDOIs[0]
+		} else {
+			clog.printWarning(artIndex+1, "DOI", "DOI not found in article")
 		}
 		normArt.doi = doi
 
-		splittedAuthorsTitleAndMeta := yearRegex.Split(art, 2)
-		if len(splittedAuthorsTitleAndMeta) < 2 {
-			printError(artIndex+1, "YEAR: Cannot split authors/title by year pattern")
+		// HEADER: "Authors. Year. Title // Journal. Vol.X. No.Y. P.a–b."
+		// citationLine is where the header starts: a rubric or type line may come first
+		citation, citationLine, ok := findArticleCitation(artStrings)
+		if !ok {
+			clog.printWarning(artIndex+1, "HEADER", "Citation line not recognised, splitting on the first four-digit number")
+			citation, ok = parseHeaderLegacy(art)
+		}
+		if ok && typeSource == "" {
+			if t, found := articleTypeFromTitle(citation.title); found {
+				normArt.articleType, typeSource = t, "title"
+			}
+		}
+		if typeSource != "" {
+			clog.Printf("📄 [Article %d] Type: %s (from %s)\n", artIndex+1, normArt.articleType, typeSource)
+		}
+		if normArt.articleType == ArticleErratum {
+			own, update, problems := linkCorrigendum(artStrings, headerDOIs, citation)
+			for _, msg := range problems {
+				clog.printWarning(artIndex+1, "CORRIGENDUM", msg)
+			}
+			doi, normArt.doi, normArt.updates = own, own, update
+			if update.DOI != "" {
+				clog.Printf("🔗 [Article %d] %s of %s\n", artIndex+1, update.Type, update.DOI)
+			}
+		} else {
+			for _, found := range headerDOIs[min(1, len(headerDOIs)):] {
+				clog.printWarning(artIndex+1, "DOI", fmt.Sprintf("Several DOIs in article header (%s, %s), using the first", doi, found))
+			}
+		}
+		checkArticleRequirements(clog, artIndex+1, normArt)
+		if !ok {
+			clog.printError(artIndex+1, "YEAR: Cannot split authors/title by year pattern")
 			// Continue processing with empty values
 			normArt.title = ""
-			normArt.pages = ""
 			normArt.authors = ""
 			normArt.affiliations = ""
 			articlesNormalized[artIndex] = normArt
 			continue
 		}
-
-		authorsRaw, titleAndMeta := splittedAuthorsTitleAndMeta[0], splittedAuthorsTitleAndMeta[1]
-		splittedTitleMeta := strings.Split(titleAndMeta, "//")
-		// If there's no "//" in the string, split by "/"
-		if len(splittedTitleMeta) == 1 {
-			splittedTitleMeta = strings.Split(titleAndMeta, "/")
+		normArt.citation = citation
+		for _, msg := range citation.checkAgainstDOI(doi) {
+			clog.printWarning(artIndex+1, "HEADER", msg)
 		}
 
-		title, numberMeta := splittedTitleMeta[0], splittedTitleMeta[1]
-		normArt.title = strings.TrimSpace(strings.TrimPrefix(title, "."))
-		normArt.pages = formatPageNumbers(pagesRegex.FindString(numberMeta))
+		authorsRaw := citation.authorsRaw
+		normArt.title = citation.title
+		if pages, ok := parsePages(citation.meta); ok {
+			normArt.pages = pages
+		} else {
+			// articles.pages stays empty, the editor fills it in
+			clog.printWarning(artIndex+1, "PAGES", fmt.Sprintf("Cannot recognise pages in %q", strings.TrimSpace(citation.meta)))
+		}
 		// (start) ----- AUTHORS BLOCK -------
 		authorsNormalized := []string{}
 		for _, auth := range strings.Split(authorsRaw, ", ") {
-			authorsNormalized = append(authorsNormalized, authSuffxRegex.ReplaceAllStringFunc(auth, deleteSubstring))
+			authorsNormalized = append(authorsNormalized, authSuffixRegex.ReplaceAllStringFunc(auth, deleteSubstring))
 		}
 		normArt.authors = strings.TrimSpace(strings.Join(authorsNormalized, ", "))
+
+		// Emails and ORCID iDs live in the header lines between the citation line and the abstract
+		var headerLines []string
+		for _, str := range artStrings[min(citationLine+1, len(artStrings)):] {
+			if abstractEnRegex.MatchString(str) || abstractRuRegex.MatchString(str) {
+				break
+			}
+			headerLines = append(headerLines, str)
+		}
+		contacts, contactProblems := extractContacts(headerLines, strings.Split(authorsRaw, ", "))
+		for _, msg := range contactProblems {
+			clog.printWarning(artIndex+1, "CONTACTS", msg)
+		}
+		normArt.emails = contacts.emails
+		normArt.orcids = contacts.orcids
 		// (end) ----- AUTHORS BLOCK -------
 		// (start) ----- AFFILIATIONS BLOCK -------
 		// authorsRaw is just surnames with or without number, so we extract digits here
@@ -248,19 +272,19 @@ func processDocument(docPath, outputPath string) error {
 			affiliationLine := ""
 			for i, str := range artStrings {
 				// Skip the first line (title/metadata) and look for lines with email or address patterns
-				if i > 0 && (strings.Contains(str, "@") || strings.Contains(str, "E-mail") || strings.Contains(str, "Email") || strings.Contains(str, "Russia") || strings.Contains(str, "China") || strings.Contains(str, "USA")) {
+				if i > 0 && (strings.Contains(str, "@") || strings.Contains(str, "E-mail") || strings.Contains(str, "Email") || isAddressLine(str)) {
 					affiliationLine = str
 					break
 				}
 			}
 
 			if affiliationLine != "" {
-				fmt.Println("Affiliation (no enumeration):", affiliationLine)
+				clog.Println("Affiliation (no enumeration):", affiliationLine)
 				for j := range affilations {
 					affilations[j] = strings.TrimPrefix(affiliationLine, "1")
 				}
 			} else {
-				fmt.Println("Warning: No affiliation data found for article", artIndex+1)
+				clog.Println("Warning: No affiliation data found for article", artIndex+1)
 				for j := range affilations {
 					affilations[j] = ""
 				}
@@ -271,17 +295,17 @@ func processDocument(docPath, outputPath string) error {
 				affiliationsNumerated = make([]string, len(artStrings)-1)
 				copy(affiliationsNumerated, artStrings[1:])
 			} else {
-				fmt.Println("Warning: No affiliation data found for article", artIndex+1)
+				clog.Println("Warning: No affiliation data found for article", artIndex+1)
 			}
 			for i, match := range authorAffilNums {
 				idx := slices.IndexFunc(affiliationsNumerated, func(s string) bool { return strings.HasPrefix(s, match[1]) })
 				if idx != -1 {
 					if i >= len(affilations) {
-						printError(artIndex+1, "AFFILIATIONS: More affiliation numbers on authors than available affiliations")
+						clog.printError(artIndex+1, "AFFILIATIONS: More affiliation numbers on authors than available affiliations")
 					}
 					affilations[i] = strings.TrimPrefix(affiliationsNumerated[idx], match[1])
 				} else {
-					printWarning(artIndex+1, "AFFILIATIONS", fmt.Sprintf("Affiliation number %s not found in text", match[1]))
+					clog.printWarning(artIndex+1, "AFFILIATIONS", fmt.Sprintf("Affiliation number %s not found in text", match[1]))
 				}
 			}
 		}
@@ -297,15 +321,60 @@ func processDocument(docPath, outputPath string) error {
 			affilations[i] = strings.TrimSuffix(strings.TrimSpace(affilations[i]), ".")
 		}
 		normArt.affiliations = strings.Join(affilations, "; ")
+
+		// Match affiliations against the organization registry, take the country from the gazetteer
+		normArt.affiliationOrgs = make([]string, len(affilations))
+		normArt.countries = make([]string, len(affilations))
+		for i, aff := range affilations {
+			if aff == "" {
+				continue
+			}
+			split := splitAffiliation(aff)
+			normArt.affiliationOrgs[i] = orgs.Match(split).String()
+			normArt.countries[i] = split.CountryCode
+			switch {
+			case split.CountryCode != "":
+			case split.Country != "":
+				clog.printWarning(artIndex+1, "COUNTRY", fmt.Sprintf("Country %q of author %d is not in the gazetteer", split.Country, i+1))
+			default:
+				clog.printWarning(artIndex+1, "COUNTRY", fmt.Sprintf("No country in the affiliation of author %d", i+1))
+			}
+		}
 		articlesNormalized[artIndex] = normArt
-		fmt.Printf("✓ [Article %d] Parsed successfully: DOI=%s, Title='%s'\n", artIndex+1, normArt.doi, normArt.title[:min(50, len(normArt.title))])
+		clog.Printf("✓ [Article %d] Parsed successfully: DOI=%s, Title='%s'\n", artIndex+1, normArt.doi, normArt.title[:min(50, len(normArt.title))])
 		// (end) ----- AFFILIATIONS BLOCK -------
 	}
 
+	// Check DOIs against the registry pattern and document order
+	dois := make([]string, len(articlesNormalized))
+	for i, art := range articlesNormalized {
+		dois[i] = art.doi
+	}
+	doiProblems := checkDOISequence(dois)
+	for i := range articlesNormalized {
+		for _, msg := range doiProblems[i+1] {
+			clog.printWarning(i+1, "DOI", msg)
+		}
+	}
+
+	// Check page ranges for consistency and contiguity in document order
+	var prevPages *PageRange
+	for i := range articlesNormalized {
+		pages := articlesNormalized[i].pages
+		if pages == (PageRange{}) {
+			prevPages = nil
+			continue
+		}
+		for _, msg := range pages.Validate(prevPages) {
+			clog.printWarning(i+1, "PAGES", msg)
+		}
+		prevPages = &articlesNormalized[i].pages
+	}
+
 	f := excelize.NewFile()
 	defer func() {
 		if err := f.Close(); err != nil {
-			fmt.Println(err)
+			clog.Println(err)
 		}
 	}()
 
@@ -329,6 +398,18 @@ func processDocument(docPath, outputPath string) error {
 		{"J1", "articles.summary"},
 		{"K1", "articles.number"},
 		{"L1", "articles.DOI"},
+		{"M1", "articles.title_ru"},
+		{"N1", "articles.authors_ru"},
+		{"O1", "articles.key_words_ru"},
+		{"P1", "articles.summary_ru"},
+		{"Q1", "articles.emails"},
+		{"R1", "articles.orcids"},
+		{"S1", "articles.affiliations_ror"},
+		{"T1", "articles.countries"},
+		{"U1", "articles.type"},
+		{"V1", "articles.updates_doi"},
+		{"W1", "articles.updates_type"},
+		{"X1", "articles.updates_citation"},
 	}
 
 	for _, h := range headers {
@@ -347,6 +428,28 @@ func processDocument(docPath, outputPath string) error {
 		{"D1", "ref.title"},
 		{"E1", "ref.meta"},
 		{"F1", "ref.art_doi"},
+		{"G1", "ref.type"},
+		{"H1", "ref.container"},
+		{"I1", "ref.volume"},
+		{"J1", "ref.issue"},
+		{"K1", "ref.pages"},
+		{"L1", "ref.city"},
+		{"M1", "ref.publisher"},
+		{"N1", "ref.total_pages"},
+		{"O1", "ref.url"},
+		{"P1", "ref.access_date"},
+		{"Q1", "ref.et_al"},
+		{"R1", "ref.doi"},
+		{"S1", "ref.pmid"},
+		{"T1", "ref.isbn"},
+		{"U1", "ref.suggested_doi"},
+		{"V1", "ref.suggested_doi_score"},
+		{"W1", "ref.suggested_title"},
+		{"X1", "ref.title_translated"},
+		{"Y1", "ref.language"},
+		{"Z1", "ref.confidence"},
+		{"AA1", "ref.heuristics"},
+		{"AB1", "ref.style"},
 	}
 
 	for _, h := range refHeaders {
@@ -382,6 +485,40 @@ func processDocument(docPath, outputPath string) error {
 		f.SetCellValue("pubdate", h.cell, h.value)
 	}
 
+	f.NewSheet("keywords")
+
+	keywordHeaders := []struct {
+		cell  string
+		value string
+	}{
+		{"A1", "keyword.art_doi"},
+		{"B1", "keyword.lang"},
+		{"C1", "keyword.position"},
+		{"D1", "keyword.value"},
+	}
+
+	for _, h := range keywordHeaders {
+		f.SetCellValue("keywords", h.cell, h.value)
+	}
+
+	f.NewSheet("reference-authors")
+
+	// One row per cited author; ref_row is the reference's row on the References sheet
+	refAuthorHeaders := []struct {
+		cell  string
+		value string
+	}{
+		{"A1", "ref_author.art_doi"},
+		{"B1", "ref_author.ref_row"},
+		{"C1", "ref_author.position"},
+		{"D1", "ref_author.surname"},
+		{"E1", "ref_author.initials"},
+	}
+
+	for _, h := range refAuthorHeaders {
+		f.SetCellValue("reference-authors", h.cell, h.value)
+	}
+
 	// Parse web data BEFORE filling the articles sheet
 	// Extract journal info from the first article's DOI
 	var journalInfo JournalInfo
@@ -392,28 +529,56 @@ func processDocument(docPath, outputPath string) error {
 		return fmt.Errorf("first article has no DOI - cannot determine journal information")
 	}
 
-	fmt.Println("Using DOI from first article:", articlesNormalized[0].doi)
-	journalInfo = GetJournalPage(articlesNormalized[0].doi)
-	fmt.Printf("Journal Info - Volume: %s, Issue: %s, Pubdate: %s, Articles: %d\n",
+	clog.Println("Using DOI from first article:", articlesNormalized[0].doi)
+	clog.stage("scraping", 0, 0)
+	journalInfo, err = GetJournalPage(articlesNormalized[0].doi)
+	if err != nil {
+		return fmt.Errorf("failed to get the issue page: %w", err)
+	}
+	clog.Printf("Journal Info - Volume: %s, Issue: %s, Pubdate: %s, Articles: %d\n",
 		journalInfo.Volume, journalInfo.Issue, journalInfo.Pubdate, len(journalInfo.Links))
 
+	for i, art := range articlesNormalized {
+		for _, msg := range art.citation.checkAgainstJournalInfo(journalInfo) {
+			clog.printWarning(i+1, "HEADER", msg)
+		}
+	}
+
+	journalCode, err := ExtractJournalCodeFromDOI(articlesNormalized[0].doi)
+	if err != nil {
+		return fmt.Errorf("failed to extract journal code: %w", err)
+	}
+	stateManager := NewStateManager()
+	// The state file also holds the journal's settings (citation style, page format);
+	// they are read here, the numbering is read again under the journal's lock below
+	settings, err := stateManager.LoadState(journalCode)
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+	journal := configuredJournal(journalCode, settings)
+	pageFormat := journal.Pages
+
 	f.SetCellValue("pubdate", "A1", journalInfo.Volume)
 	f.SetCellValue("pubdate", "B1", journalInfo.Issue)
 	f.SetCellValue("pubdate", "C1", journalInfo.Pubdate)
 
 	// Fill the Doi sheet with article links
 	for i, link := range journalInfo.Links {
-		fmt.Println(link)
+		clog.Println(link)
 		f.SetCellValue("doi", fmt.Sprintf("A%s", strconv.Itoa(i+1)), link)
 	}
 
-	// STATE MANAGEMENT: Load state and handle numbering
-	journalCode, err := ExtractJournalCodeFromDOI(articlesNormalized[0].doi)
+	// Parse references and look up missing DOIs before the journal's state is locked:
+	// with REFMATCH_URL set the lookups may take up to refMatchBudget
+	articleRefs, err := parseArticleReferences(articlesNormalized, journal, newReferenceMatcher(), clog)
 	if err != nil {
-		return fmt.Errorf("failed to extract journal code: %w", err)
+		return err
 	}
 
-	stateManager := NewStateManager()
+	// STATE MANAGEMENT: Load state and handle numbering
+	// Held until the issue is recorded: another conversion of the journal would allocate the same numbers
+	unlock := stateManager.Lock(journalCode)
+	defer unlock()
 	state, err := stateManager.LoadState(journalCode)
 	if err != nil {
 		return fmt.Errorf("failed to load state: %w", err)
@@ -421,6 +586,10 @@ func processDocument(docPath, outputPath string) error {
 
 	// Check if state is configured (starting point set)
 	if !stateManager.IsConfigured(state) {
+		// A worker cannot prompt: it would wait forever holding the journal's lock
+		if !clog.attended() {
+			return fmt.Errorf("numbering state file %s has no starting point: set starting_point.counter in it and convert again", state.stateFilePath)
+		}
 		if err := stateManager.PromptForStartingPoint(state, journalInfo.Volume, journalInfo.Issue); err != nil {
 			return fmt.Errorf("failed to configure state: %w", err)
 		}
@@ -429,14 +598,14 @@ func processDocument(docPath, outputPath string) error {
 	// Check for duplicate issue
 	var startNum, endNum int
 	if existingIssue, isDuplicate := stateManager.IsIssueProcessed(state, journalInfo.Volume, journalInfo.Issue); isDuplicate {
-		action := stateManager.HandleDuplicateIssue(*existingIssue, journalCode)
+		action := stateManager.HandleDuplicateIssue(*existingIssue, journalCode, clog)
 
 		switch action {
 		case SkipProcessing:
-			fmt.Println("Skipping processing as requested.")
+			clog.Println("Skipping processing as requested.")
 			return nil
 		case ReprocessSameNumbers:
-			fmt.Printf("Reprocessing with existing numbers %d-%d\n", existingIssue.StartNumber, existingIssue.EndNumber)
+			clog.Printf("Reprocessing with existing numbers %d-%d\n", existingIssue.StartNumber, existingIssue.EndNumber)
 			startNum = existingIssue.StartNumber
 			endNum = existingIssue.EndNumber
 			// Remove old entry so we can add the new one
@@ -444,26 +613,33 @@ func processDocument(docPath, outputPath string) error {
 				return fmt.Errorf("failed to remove old issue entry: %w", err)
 			}
 		case ReprocessNewNumbers:
-			fmt.Println("Reprocessing with NEW numbers")
+			clog.Println("Reprocessing with NEW numbers")
 			// Remove old entry
 			if err := stateManager.RemoveIssue(state, journalInfo.Volume, journalInfo.Issue); err != nil {
 				return fmt.Errorf("failed to remove old issue entry: %w", err)
 			}
 			// Allocate new numbers
 			startNum, endNum = stateManager.AllocateNumbers(state, len(articlesNormalized))
-			fmt.Printf("Allocated new numbers: %d-%d\n", startNum, endNum)
+			clog.Printf("Allocated new numbers: %d-%d\n", startNum, endNum)
 		case Abort:
 			return fmt.Errorf("processing aborted by user")
 		}
 	} else {
 		// New issue: allocate numbers
 		startNum, endNum = stateManager.AllocateNumbers(state, len(articlesNormalized))
-		fmt.Printf("Allocated article numbers: %d-%d\n", startNum, endNum)
+		clog.Printf("Allocated article numbers: %d-%d\n", startNum, endNum)
 	}
 
 	// Now fill the articles sheet with parsed data and web data
 	var refI = 1 // Start at 1 because row 1 has headers
+	var kwI = 1
+	var refAuthI = 1
+	lowConfidenceRefs := 0
+	// Citations to our journals for the journal-citations sheet
+	journalCitations := journalCitationCounts{}
+	citingYear := citationYearRegex.FindString(journalInfo.Pubdate)
 	for artI, art := range articlesNormalized {
+		clog.stage("writing", artI+1, len(articlesNormalized))
 		artNumStr := strconv.Itoa(artI + 1)
 		// Row index is artI + 2 (skip header row)
 		rowNum := strconv.Itoa(artI + 2)
@@ -481,6 +657,16 @@ func processDocument(docPath, outputPath string) error {
 		// J: articles.summary
 		// K: articles.number
 		// L: articles.DOI
+		// M: articles.title_ru
+		// N: articles.authors_ru
+		// O: articles.key_words_ru
+		// P: articles.summary_ru
+		// Q: articles.emails (per author, "; "-separated like affiliations)
+		// R: articles.orcids (per author)
+		// S: articles.affiliations_ror (per author, canonical name and registry ID)
+		// T: articles.countries (per author, ISO 3166-1 alpha-2 code)
+		// U: articles.type (research_article, short_communication, review, obituary, erratum, editorial)
+		// V-X: articles.updates_doi, updates_type, updates_citation (corrections: the corrected article)
 
 		// Fill total_number from allocated range
 		totalNumber := startNum + artI
@@ -488,34 +674,127 @@ func processDocument(docPath, outputPath string) error {
 		f.SetCellValue("articles", fmt.Sprintf("B%s", rowNum), journalInfo.Pubdate)
 		f.SetCellValue("articles", fmt.Sprintf("C%s", rowNum), journalInfo.Volume)
 		f.SetCellValue("articles", fmt.Sprintf("D%s", rowNum), journalInfo.Issue)
-		f.SetCellValue("articles", fmt.Sprintf("E%s", rowNum), art.pages)
+		f.SetCellValue("articles", fmt.Sprintf("E%s", rowNum), art.formattedPages(pageFormat))
 		f.SetCellValue("articles", fmt.Sprintf("F%s", rowNum), art.authors)
 		f.SetCellValue("articles", fmt.Sprintf("G%s", rowNum), art.affiliations)
 		f.SetCellValue("articles", fmt.Sprintf("H%s", rowNum), art.title)
-		f.SetCellValue("articles", fmt.Sprintf("I%s", rowNum), art.keywords)
-		f.SetCellValue("articles", fmt.Sprintf("J%s", rowNum), art.abstract)
+		f.SetCellValue("articles", fmt.Sprintf("I%s", rowNum), strings.Join(art.keywordsEn, ", "))
+		f.SetCellValue("articles", fmt.Sprintf("J%s", rowNum), art.abstractEn)
 		f.SetCellValue("articles", fmt.Sprintf("K%s", rowNum), artNumStr)
 		f.SetCellValue("articles", fmt.Sprintf("L%s", rowNum), art.doi)
+		f.SetCellValue("articles", fmt.Sprintf("M%s", rowNum), art.titleRu)
+		f.SetCellValue("articles", fmt.Sprintf("N%s", rowNum), art.authorsRu)
+		f.SetCellValue("articles", fmt.Sprintf("O%s", rowNum), strings.Join(art.keywordsRu, ", "))
+		f.SetCellValue("articles", fmt.Sprintf("P%s", rowNum), art.abstractRu)
+		f.SetCellValue("articles", fmt.Sprintf("Q%s", rowNum), strings.Join(art.emails, "; "))
+		f.SetCellValue("articles", fmt.Sprintf("R%s", rowNum), strings.Join(art.orcids, "; "))
+		f.SetCellValue("articles", fmt.Sprintf("S%s", rowNum), strings.Join(art.affiliationOrgs, "; "))
+		f.SetCellValue("articles", fmt.Sprintf("T%s", rowNum), strings.Join(art.countries, "; "))
+		f.SetCellValue("articles", fmt.Sprintf("U%s", rowNum), art.articleType.String())
+		f.SetCellValue("articles", fmt.Sprintf("V%s", rowNum), art.updates.DOI)
+		f.SetCellValue("articles", fmt.Sprintf("W%s", rowNum), art.updates.Type)
+		f.SetCellValue("articles", fmt.Sprintf("X%s", rowNum), art.updates.Citation)
 		doiSheetdoiCell := fmt.Sprintf("B%s", artNumStr)
 
 		f.SetCellValue("doi", doiSheetdoiCell, art.doi)
-		fmt.Printf("Article %d: Success\n", artI+1)
+		clog.Printf("Article %d: Success\n", artI+1)
+
+		for _, kwList := range []struct {
+			lang     string
+			keywords []string
+		}{{"en", art.keywordsEn}, {"ru", art.keywordsRu}} {
+			for pos, kw := range kwList.keywords {
+				kwI += 1
+				f.SetCellValue("keywords", fmt.Sprintf("A%d", kwI), art.doi)
+				f.SetCellValue("keywords", fmt.Sprintf("B%d", kwI), kwList.lang)
+				f.SetCellValue("keywords", fmt.Sprintf("C%d", kwI), pos+1)
+				f.SetCellValue("keywords", fmt.Sprintf("D%d", kwI), kw)
+			}
+		}
 
-		for artRefI, ref := range art.references {
+		refs := articleRefs[artI]
+		style := refs.style
+		if style.Name() != defaultCitationStyle {
+			clog.Printf("📚 [Article %d] References parsed as %s style\n", artI+1, strings.ToUpper(style.Name()))
+		}
+
+		for artRefI, ref := range refs.texts {
 			refI += 1
-			if artRefI == len(art.references)-1 {
-				ref = strings.TrimSuffix(ref, ">>>")
-			}
 			f.SetCellValue("References", fmt.Sprintf("A%s", strconv.Itoa(refI)), ref)
-			authors, year, title, meta := parseReference(ref)
-			f.SetCellValue("References", fmt.Sprintf("B%s", strconv.Itoa(refI)), authors)
-			f.SetCellValue("References", fmt.Sprintf("C%s", strconv.Itoa(refI)), year)
-			f.SetCellValue("References", fmt.Sprintf("D%s", strconv.Itoa(refI)), title)
-			f.SetCellValue("References", fmt.Sprintf("E%s", strconv.Itoa(refI)), meta)
+			pr := refs.parsed[artRefI]
+			f.SetCellValue("References", fmt.Sprintf("B%s", strconv.Itoa(refI)), pr.Authors)
+			f.SetCellValue("References", fmt.Sprintf("C%s", strconv.Itoa(refI)), pr.Year)
+			f.SetCellValue("References", fmt.Sprintf("D%s", strconv.Itoa(refI)), pr.Title)
+			f.SetCellValue("References", fmt.Sprintf("E%s", strconv.Itoa(refI)), pr.Meta)
 			f.SetCellValue("References", fmt.Sprintf("F%s", strconv.Itoa(refI)), art.doi)
+			f.SetCellValue("References", fmt.Sprintf("G%d", refI), pr.Type.String())
+			f.SetCellValue("References", fmt.Sprintf("H%d", refI), pr.Container)
+			f.SetCellValue("References", fmt.Sprintf("I%d", refI), pr.Volume)
+			f.SetCellValue("References", fmt.Sprintf("J%d", refI), pr.Issue)
+			f.SetCellValue("References", fmt.Sprintf("K%d", refI), pr.Pages)
+			f.SetCellValue("References", fmt.Sprintf("L%d", refI), pr.City)
+			f.SetCellValue("References", fmt.Sprintf("M%d", refI), pr.Publisher)
+			f.SetCellValue("References", fmt.Sprintf("N%d", refI), pr.TotalPages)
+			f.SetCellValue("References", fmt.Sprintf("O%d", refI), pr.URL)
+			f.SetCellValue("References", fmt.Sprintf("P%d", refI), pr.AccessDate)
+			if pr.EtAl {
+				f.SetCellValue("References", fmt.Sprintf("Q%d", refI), "et al.")
+			}
+			f.SetCellValue("References", fmt.Sprintf("R%d", refI), pr.DOI)
+			f.SetCellValue("References", fmt.Sprintf("S%d", refI), pr.PMID)
+			f.SetCellValue("References", fmt.Sprintf("T%d", refI), pr.ISBN)
+			f.SetCellValue("References", fmt.Sprintf("X%d", refI), pr.TitleTranslated)
+			f.SetCellValue("References", fmt.Sprintf("Y%d", refI), pr.Language)
+			f.SetCellValue("References", fmt.Sprintf("Z%d", refI), math.Round(pr.Confidence*100)/100)
+			f.SetCellValue("References", fmt.Sprintf("AA%d", refI), strings.Join(pr.Heuristics, "; "))
+			f.SetCellValue("References", fmt.Sprintf("AB%d", refI), style.Name())
+			if pr.Confidence < lowConfidenceThreshold {
+				lowConfidenceRefs++
+			}
+			if j, ok := referenceJournal(ref, pr.Title, pr.Meta, pr.DOI); ok {
+				journalCitations.Add(j.Code, pr.Year, citingYear)
+			}
+
+			// Suggested DOI for a reference without one; the editor reviews the suggestions
+			if suggestion := refs.suggestions[artRefI]; suggestion != nil {
+				f.SetCellValue("References", fmt.Sprintf("U%d", refI), suggestion.DOI)
+				f.SetCellValue("References", fmt.Sprintf("V%d", refI), math.Round(suggestion.Score*100)/100)
+				f.SetCellValue("References", fmt.Sprintf("W%d", refI), suggestion.Title)
+			}
+
+			for pos, a := range pr.AuthorList {
+				refAuthI += 1
+				f.SetCellValue("reference-authors", fmt.Sprintf("A%d", refAuthI), art.doi)
+				f.SetCellValue("reference-authors", fmt.Sprintf("B%d", refAuthI), refI)
+				f.SetCellValue("reference-authors", fmt.Sprintf("C%d", refAuthI), pos+1)
+				f.SetCellValue("reference-authors", fmt.Sprintf("D%d", refAuthI), a.Surname)
+				f.SetCellValue("reference-authors", fmt.Sprintf("E%d", refAuthI), a.Initials)
+			}
 		}
 	}
 
+	// Highlight references the parser is unsure about
+	if refI > 1 {
+		lowStyle, err := f.NewConditionalStyle(&excelize.Style{
+			Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"#FFC7CE"}},
+			Font: &excelize.Font{Color: "#9C0006"},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create conditional style: %w", err)
+		}
+		err = f.SetConditionalFormat("References", fmt.Sprintf("A2:AB%d", refI), []excelize.ConditionalFormatOptions{
+			{Type: "formula", Criteria: fmt.Sprintf("$Z2<%g", lowConfidenceThreshold), Format: lowStyle},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to set conditional format: %w", err)
+		}
+	}
+	if lowConfidenceRefs > 0 {
+		clog.Printf("⚠️  %d of %d references parsed with low confidence (highlighted on the References sheet)\n", lowConfidenceRefs, refI-1)
+	}
+
+	writeJournalCitationSheet(f, "journal-citations", journalCitations)
+
 	// Save spreadsheet by the given path.
 	if err := f.SaveAs(outputPath); err != nil {
 		return fmt.Errorf("failed to save Excel file: %w", err)
@@ -536,8 +815,8 @@ func processDocument(docPath, outputPath string) error {
 		return fmt.Errorf("failed to update state: %w", err)
 	}
 
-	fmt.Printf("\n✓ State updated: articles numbered %d-%d\n", startNum, endNum)
-	fmt.Printf("✓ Excel file saved: %s\n", outputPath)
+	clog.Printf("\n✓ State updated: articles numbered %d-%d\n", startNum, endNum)
+	clog.Printf("✓ Excel file saved: %s\n", outputPath)
 
 	return nil
 }
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+	"gopkg.in/yaml.v3"
+)
+
+/*
+END-TO-END FIXTURES
+
+Every directory in testdata/e2e is one sample issue run through processDocument:
+
+	issue.docx, .odt or .doc    anonymized issue document
+	index.html                  saved kmkjournals "Index_Volumes" page for the journal
+	state/<CODE>_state.yaml     numbering state before the conversion
+	organizations.json          organization registry (optional, none without it)
+	expected.json               expected workbook: sheet -> rows -> cells
+
+The index page is served by a local HTTP server (kmkjournalsBaseURL), state
+files are copied to a temp STATE_DIR and the test runs in a temp working
+directory, because the conversion writes output.txt there. Matching and style
+overrides from the environment are switched off, and the cached organization
+registry is dropped so every fixture reads its own.
+
+Links on the doi sheet are compared with the local server's address replaced by
+https://kmkjournals.com. After an intended change to the output, regenerate the
+expected workbooks and review the diff:
+
+	go test -run TestProcessDocumentFixtures -update .
+
+.doc fixtures need catdoc or wvText and are skipped without them.
+*/
+
+var updateFixtures = flag.Bool("update", false, "rewrite expected.json of the end-to-end fixtures")
+
+// fixtureWorkbook is a workbook as sheet name -> rows -> cell values
+type fixtureWorkbook map[string][][]string
+
+func TestProcessDocumentFixtures(t *testing.T) {
+	dirs, err := filepath.Glob("testdata/e2e/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dirs) == 0 {
+		t.Fatal("no fixtures in testdata/e2e")
+	}
+
+	for _, dir := range dirs {
+		dir, err := filepath.Abs(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			runDocumentFixture(t, dir)
+		})
+	}
+}
+
+func runDocumentFixture(t *testing.T, dir string) {
+	docPath, work, serverURL := setupFixture(t, dir)
+	stateDir := filepath.Join(work, "state")
+
+	outPath := filepath.Join(work, "output.xlsx")
+	if err := processDocument(docPath, outPath, nil); err != nil {
+		t.Fatalf("processDocument: %v", err)
+	}
+
+	got, err := readFixtureWorkbook(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got.replaceServerURL(serverURL)
+
+	expectedPath := filepath.Join(dir, "expected.json")
+	if *updateFixtures {
+		data, err := json.MarshalIndent(got, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(expectedPath, append(data, '\n'), 0644); err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("updated %s", expectedPath)
+	} else {
+		compareWorkbooks(t, got, readExpectedWorkbook(t, dir))
+	}
+
+	checkFixtureState(t, stateDir)
+}
+
+// setupFixture serves the fixture's index page as kmkjournals, copies its state to
+// a temp STATE_DIR and changes to a temp working directory, which it returns
+func setupFixture(t *testing.T, dir string) (docPath, work, serverURL string) {
+	t.Helper()
+	docPath = findFixtureDocument(t, dir)
+	if filepath.Ext(docPath) == ".doc" && !hasDocConverter() {
+		t.Skip("no catdoc or wvText for .doc fixtures")
+	}
+
+	index, err := os.ReadFile(filepath.Join(dir, "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "_Index_Volumes") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(index)
+	}))
+	t.Cleanup(srv.Close)
+	base := kmkjournalsBaseURL
+	t.Cleanup(func() { kmkjournalsBaseURL = base })
+	kmkjournalsBaseURL = srv.URL
+
+	work = t.TempDir()
+	stateDir := filepath.Join(work, "state")
+	copyFixtureDir(t, filepath.Join(dir, "state"), stateDir)
+	t.Setenv("STATE_DIR", stateDir)
+	for _, name := range []string{"CITATION_STYLE", "REFMATCH_URL", "SEGMENTATION"} {
+		t.Setenv(name, "")
+	}
+	// organizations.json is the fixture's registry; without it ORG_REGISTRY points
+	// at registry/organizations.json in the empty working directory
+	registry := filepath.Join(dir, "organizations.json")
+	if _, err := os.Stat(registry); err != nil {
+		registry = ""
+	}
+	t.Setenv("ORG_REGISTRY", registry)
+	resetOrgRegistry()
+	t.Cleanup(resetOrgRegistry)
+	chdir(t, work)
+	return docPath, work, srv.URL
+}
+
+// replaceServerURL turns links to the local index server into kmkjournals links
+func (wb fixtureWorkbook) replaceServerURL(serverURL string) {
+	for _, rows := range wb {
+		for _, row := range rows {
+			for i := range row {
+				row[i] = strings.ReplaceAll(row[i], serverURL, "https://kmkjournals.com")
+			}
+		}
+	}
+}
+
+// readExpectedWorkbook reads expected.json of a fixture
+func readExpectedWorkbook(t *testing.T, dir string) fixtureWorkbook {
+	t.Helper()
+	expectedPath := filepath.Join(dir, "expected.json")
+	data, err := os.ReadFile(expectedPath)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+	var want fixtureWorkbook
+	if err := json.Unmarshal(data, &want); err != nil {
+		t.Fatalf("failed to parse %s: %v", expectedPath, err)
+	}
+	return want
+}
+
+// compareWorkbooks reports every sheet and cell that differs
+func compareWorkbooks(t *testing.T, got, want fixtureWorkbook) {
+	t.Helper()
+	for sheet := range want {
+		if _, ok := got[sheet]; !ok {
+			t.Errorf("sheet %q missing", sheet)
+		}
+	}
+	for sheet, gotRows := range got {
+		wantRows, ok := want[sheet]
+		if !ok {
+			t.Errorf("unexpected sheet %q", sheet)
+			continue
+		}
+		for r := 0; r < max(len(gotRows), len(wantRows)); r++ {
+			gotRow, wantRow := rowAt(gotRows, r), rowAt(wantRows, r)
+			for c := 0; c < max(len(gotRow), len(wantRow)); c++ {
+				g, w := cellAt(gotRow, c), cellAt(wantRow, c)
+				if g != w {
+					cell, _ := excelize.CoordinatesToCellName(c+1, r+1)
+					t.Errorf("%s!%s:\n got: %q\nwant: %q", sheet, cell, g, w)
+				}
+			}
+		}
+	}
+}
+
+// checkFixtureState verifies that the conversion recorded the issue in the state file
+func checkFixtureState(t *testing.T, stateDir string) {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(stateDir, "*_state.yaml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one state file in %s, found %v (%v)", stateDir, files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var state JournalState
+	if err := yaml.Unmarshal(data, &state); err != nil {
+		t.Fatal(err)
+	}
+	if len(state.ProcessedIssues) != 1 {
+		t.Fatalf("expected one processed issue in state, got %d", len(state.ProcessedIssues))
+	}
+	if issue := state.ProcessedIssues[0]; state.CurrentCounter != issue.EndNumber+1 {
+		t.Errorf("current_counter %d, want %d after articles %d-%d",
+			state.CurrentCounter, issue.EndNumber+1, issue.StartNumber, issue.EndNumber)
+	}
+}
+
+func readFixtureWorkbook(path string) (fixtureWorkbook, error) {
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	wb := fixtureWorkbook{}
+	for _, sheet := range f.GetSheetList() {
+		rows, err := f.GetRows(sheet)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sheet %s: %w", sheet, err)
+		}
+		wb[sheet] = rows
+	}
+	return wb, nil
+}
+
+func rowAt(rows [][]string, i int) []string {
+	if i < len(rows) {
+		return rows[i]
+	}
+	return nil
+}
+
+func cellAt(row []string, i int) string {
+	if i < len(row) {
+		return row[i]
+	}
+	return ""
+}
+
+// findFixtureDocument returns the issue document of a fixture directory
+func findFixtureDocument(t *testing.T, dir string) string {
+	t.Helper()
+	for _, name := range []string{"issue.docx", "issue.odt", "issue.doc"} {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	t.Fatalf("no issue.docx, issue.odt or issue.doc in %s", dir)
+	return ""
+}
+
+func hasDocConverter() bool {
+	for _, name := range []string{"catdoc", "wvText"} {
+		if _, err := exec.LookPath(name); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func copyFixtureDir(t *testing.T, src, dst string) {
+	t.Helper()
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		data, err := os.ReadFile(filepath.Join(src, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dst, e.Name()), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// chdir changes the working directory for the rest of the test
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+ORGANIZATION REGISTRY
+
+Affiliations are matched against a local dump of known organizations in the
+ROR schema (https://ror.org), so the same institution spelled differently
+across issues gets one canonical name and ID.
+
+The file is a JSON array of ROR records; only these fields are used:
+
+	[{"id": "https://ror.org/...", "name": "Lomonosov Moscow State University",
+	  "aliases": ["Moscow State University"], "acronyms": ["MSU"],
+	  "labels": [{"label": "Московский государственный университет имени М.В. Ломоносова"}],
+	  "country": {"country_code": "RU"}}]
+
+A full ROR data dump can be used as is, or a smaller file with just the
+organizations our authors come from. Path: ORG_REGISTRY environment variable,
+default registry/organizations.json. Without the file matching is skipped.
+*/
+
+const (
+	defaultOrgRegistryPath = "registry/organizations.json"
+	orgMatchThreshold      = 0.8  // minimum name similarity to accept a match
+	orgTokenSimilarity     = 0.85 // two words are the same if their edit similarity is at least this
+)
+
+// Organization is a registry record
+type Organization struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Aliases  []string `json:"aliases"`
+	Acronyms []string `json:"acronyms"`
+	Labels   []struct {
+		Label string `json:"label"`
+	} `json:"labels"`
+	Country struct {
+		Code string `json:"country_code"`
+	} `json:"country"`
+}
+
+// OrgMatch is the registry entry chosen for an affiliation
+type OrgMatch struct {
+	Org   *Organization
+	Score float64
+}
+
+// OrgRegistry is the loaded registry with a word index for candidate lookup
+type OrgRegistry struct {
+	orgs  []Organization
+	names [][]orgName      // normalized names per organization
+	index map[string][]int // word -> organizations having it in a name
+	acros map[string][]int // acronym -> organizations
+}
+
+type orgName struct {
+	tokens []string
+}
+
+// orgRegistryCache is the registry last read, with the file it was read from
+type orgRegistryCache struct {
+	path    string
+	modTime time.Time
+	size    int64
+	reg     *OrgRegistry
+}
+
+var (
+	orgRegistryMu     sync.Mutex
+	orgRegistryCached orgRegistryCache
+
+	orgPunctRegex = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+	// common abbreviations in affiliations
+	orgAbbreviations = map[string]string{
+		"univ": "university", "inst": "institute", "acad": "academy", "sci": "sciences",
+		"nat": "national", "natl": "national", "st": "state", "mus": "museum", "ras": "russian academy of sciences",
+	}
+	// words that say nothing about which organization it is
+	orgStopWords = map[string]bool{
+		"the": true, "of": true, "and": true, "for": true, "in": true, "named": true, "after": true,
+		"im": true, "имени": true, "и": true,
+	}
+	// frequent words that are not used to look up candidates
+	orgGenericWords = map[string]bool{
+		"university": true, "institute": true, "academy": true, "sciences": true, "science": true,
+		"state": true, "national": true, "russian": true, "research": true, "museum": true,
+		"federal": true, "center": true, "centre": true,
+	}
+)
+
+// loadOrgRegistry returns the registry for a conversion, nil if it is not available.
+// The status is reported in the log of every conversion.
+func loadOrgRegistry(clog *ConversionLog) *OrgRegistry {
+	path := os.Getenv("ORG_REGISTRY")
+	if path == "" {
+		path = defaultOrgRegistryPath
+	}
+	reg, err := cachedOrgRegistry(path)
+	if err != nil {
+		clog.Printf("⚠️  Organization registry not loaded (%v), affiliations will not be matched\n", err)
+		return nil
+	}
+	clog.Printf("Organization registry loaded: %d organizations from %s\n", len(reg.orgs), path)
+	return reg
+}
+
+// cachedOrgRegistry reads the registry unless the same file was read already:
+// indexing a full ROR dump takes seconds. A file that is added, fixed or
+// replaced is read by the next conversion; failures are not cached.
+func cachedOrgRegistry(path string) (*OrgRegistry, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	orgRegistryMu.Lock()
+	defer orgRegistryMu.Unlock()
+	c := orgRegistryCached
+	if c.reg != nil && c.path == path && c.modTime.Equal(info.ModTime()) && c.size == info.Size() {
+		return c.reg, nil
+	}
+	reg, err := readOrgRegistry(path)
+	if err != nil {
+		return nil, err
+	}
+	orgRegistryCached = orgRegistryCache{path: path, modTime: info.ModTime(), size: info.Size(), reg: reg}
+	return reg, nil
+}
+
+// resetOrgRegistry drops the cached registry, so the next conversion reads
+// ORG_REGISTRY again even if a file of the same path, size and time was read
+func resetOrgRegistry() {
+	orgRegistryMu.Lock()
+	defer orgRegistryMu.Unlock()
+	orgRegistryCached = orgRegistryCache{}
+}
+
+func readOrgRegistry(path string) (*OrgRegistry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var orgs []Organization
+	if err := json.Unmarshal(data, &orgs); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return newOrgRegistry(orgs), nil
+}
+
+func newOrgRegistry(orgs []Organization) *OrgRegistry {
+	reg := &OrgRegistry{
+		orgs:  orgs,
+		names: make([][]orgName, len(orgs)),
+		index: make(map[string][]int),
+		acros: make(map[string][]int),
+	}
+	for i, org := range orgs {
+		variants := append([]string{org.Name}, org.Aliases...)
+		for _, l := range org.Labels {
+			variants = append(variants, l.Label)
+		}
+		seen := make(map[string]bool)
+		for _, v := range variants {
+			tokens := orgTokens(v)
+			if len(tokens) == 0 {
+				continue
+			}
+			reg.names[i] = append(reg.names[i], orgName{tokens: tokens})
+			for _, t := range tokens {
+				if !orgGenericWords[t] && !seen[t] {
+					seen[t] = true
+					reg.index[t] = append(reg.index[t], i)
+				}
+			}
+		}
+		for _, a := range org.Acronyms {
+			key := strings.ToUpper(a)
+			reg.acros[key] = append(reg.acros[key], i)
+		}
+	}
+	return reg
+}
+
+// orgTokens normalizes an organization name into comparable words
+func orgTokens(s string) []string {
+	var tokens []string
+	for _, w := range strings.Fields(orgPunctRegex.ReplaceAllString(strings.ToLower(s), " ")) {
+		if exp, ok := orgAbbreviations[w]; ok {
+			tokens = append(tokens, strings.Fields(exp)...)
+			continue
+		}
+		if !orgStopWords[w] {
+			tokens = append(tokens, w)
+		}
+	}
+	return tokens
+}
+
+// Match finds the best registry organization for an affiliation, nil if none is close enough
+func (reg *OrgRegistry) Match(aff Affiliation) *OrgMatch {
+	if reg == nil {
+		return nil
+	}
+	name := aff.Institution
+	if name == "" {
+		name = aff.Raw
+	}
+
+	// Acronym alone: "MSU"
+	if ids := reg.acros[strings.ToUpper(strings.TrimSpace(name))]; len(ids) == 1 && reg.sameCountry(ids[0], aff) {
+		return &OrgMatch{Org: &reg.orgs[ids[0]], Score: 1}
+	}
+
+	tokens := orgTokens(name)
+	candidates := make(map[int]bool)
+	for _, t := range tokens {
+		for _, i := range reg.index[t] {
+			candidates[i] = true
+		}
+	}
+
+	ids := make([]int, 0, len(candidates))
+	for i := range candidates {
+		if reg.sameCountry(i, aff) {
+			ids = append(ids, i)
+		}
+	}
+	sort.Ints(ids) // deterministic choice between equal scores
+
+	var best *OrgMatch
+	for _, i := range ids {
+		for _, n := range reg.names[i] {
+			score := tokenDice(tokens, n.tokens)
+			if best == nil || score > best.Score {
+				best = &OrgMatch{Org: &reg.orgs[i], Score: score}
+			}
+		}
+	}
+	if best == nil || best.Score < orgMatchThreshold {
+		return nil
+	}
+	return best
+}
+
+// sameCountry is false only when both the affiliation and the organization
+// have a known country and they differ: "National Museum" in Prague is not the one in Almaty
+func (reg *OrgRegistry) sameCountry(i int, aff Affiliation) bool {
+	code := reg.orgs[i].Country.Code
+	return aff.CountryCode == "" || code == "" || strings.EqualFold(code, aff.CountryCode)
+}
+
+// tokenDice is the Dice coefficient over words, counting near-identical spellings as equal
+func tokenDice(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	used := make([]bool, len(b))
+	common := 0
+	for _, ta := range a {
+		for j, tb := range b {
+			if !used[j] && similarWords(ta, tb) {
+				used[j] = true
+				common++
+				break
+			}
+		}
+	}
+	return 2 * float64(common) / float64(len(a)+len(b))
+}
+
+func similarWords(a, b string) bool {
+	if a == b {
+		return true
+	}
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest < 5 {
+		return false // short words must match exactly
+	}
+	return 1-float64(levenshtein(ra, rb))/float64(longest) >= orgTokenSimilarity
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// String renders the match for the articles sheet: "Canonical Name (https://ror.org/...)"
+func (m *OrgMatch) String() string {
+	if m == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s (%s)", m.Org.Name, m.Org.ID)
+}
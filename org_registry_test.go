@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const orgRegistryTestJSON = `[
+  {"id": "https://ror.org/010pmpe69", "name": "Lomonosov Moscow State University",
+   "aliases": ["Moscow State University"], "acronyms": ["MSU"],
+   "labels": [{"label": "Московский государственный университет имени М.В. Ломоносова"}],
+   "country": {"country_code": "RU"}},
+  {"id": "https://ror.org/05hbexn54", "name": "Michigan State University", "acronyms": ["MSU"],
+   "country": {"country_code": "US"}},
+  {"id": "https://ror.org/02ggp4v45", "name": "Institute of Systematics and Ecology of Animals",
+   "acronyms": ["ISEA"], "country": {"country_code": "RU"}},
+  {"id": "https://ror.org/04z6gwv56", "name": "National Museum of Natural History", "country": {"country_code": "BG"}},
+  {"id": "https://ror.org/00hs7dr46", "name": "National Museum of Natural History", "country": {"country_code": "FR"}},
+  {"id": "https://ror.org/05w7r8d80", "name": "Tomsk State University", "acronyms": ["TSU"],
+   "country": {"country_code": "RU"}}
+]`
+
+func testOrgRegistry(t *testing.T) *OrgRegistry {
+	t.Helper()
+	var orgs []Organization
+	if err := json.Unmarshal([]byte(orgRegistryTestJSON), &orgs); err != nil {
+		t.Fatal(err)
+	}
+	return newOrgRegistry(orgs)
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"institute", "institute", 0},
+		{"institute", "institut", 1},
+		{"kitten", "sitting", 3},
+		{"жужелица", "жужелицы", 1},
+	}
+	for _, tt := range tests {
+		if got := levenshtein([]rune(tt.a), []rune(tt.b)); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestTokenDice(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"Tomsk State University", "Tomsk State University", 1},
+		{"Tomsk State Univ.", "Tomsk State University", 1},           // abbreviation expanded
+		{"Tomsk State Universty", "Tomsk State University", 1},       // misspelling within orgTokenSimilarity
+		{"Tomsk State University", "Tomsk University", 0.8},          // 2*2/(3+2)
+		{"Omsk State University", "Tomsk State University", 2.0 / 3}, // short words must match exactly
+		{"Institute of Zoology", "Zoological Institute", 0.5},        // "zoology" and "zoological" differ too much
+		{"", "Tomsk State University", 0},
+	}
+	for _, tt := range tests {
+		if got := tokenDice(orgTokens(tt.a), orgTokens(tt.b)); got != tt.want {
+			t.Errorf("tokenDice(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestOrgRegistryMatch(t *testing.T) {
+	reg := testOrgRegistry(t)
+	tests := []struct {
+		name string
+		aff  Affiliation
+		want string // ROR ID, "" for no match
+	}{
+		{"exact name", Affiliation{Institution: "Tomsk State University", CountryCode: "RU"}, "https://ror.org/05w7r8d80"},
+		{"alias", Affiliation{Institution: "Moscow State University"}, "https://ror.org/010pmpe69"},
+		{"Russian label", Affiliation{Institution: "Московский государственный университет им. М.В. Ломоносова"}, "https://ror.org/010pmpe69"},
+		{"unique acronym", Affiliation{Institution: "ISEA"}, "https://ror.org/02ggp4v45"},
+		// "MSU" is two universities: the country decides, without it there is no match
+		{"shared acronym in Russia", Affiliation{Institution: "MSU", CountryCode: "RU"}, ""},
+		{"shared acronym", Affiliation{Institution: "MSU"}, ""},
+		// same name in two countries
+		{"country filter", Affiliation{Institution: "National Museum of Natural History", CountryCode: "FR"}, "https://ror.org/00hs7dr46"},
+		{"other country", Affiliation{Institution: "National Museum of Natural History", CountryCode: "KZ"}, ""},
+		{"unknown country", Affiliation{Institution: "National Museum of Natural History"}, "https://ror.org/04z6gwv56"},
+		// 2*3/(3+5) = 0.75, under orgMatchThreshold
+		{"below threshold", Affiliation{Institution: "Institute of Ecology of Siberia"}, ""},
+		{"raw affiliation without institution", Affiliation{Raw: "Tomsk State University"}, "https://ror.org/05w7r8d80"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if m := reg.Match(tt.aff); m != nil {
+				got = m.Org.ID
+				if m.Score < orgMatchThreshold {
+					t.Errorf("match with score %v under the threshold", m.Score)
+				}
+			}
+			if got != tt.want {
+				t.Errorf("Match(%+v) = %q, want %q", tt.aff, got, tt.want)
+			}
+		})
+	}
+
+	var none *OrgRegistry
+	if m := none.Match(Affiliation{Institution: "Tomsk State University"}); m != nil {
+		t.Errorf("nil registry matched %v", m)
+	}
+}
+
+func TestLoadOrgRegistryRetries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "organizations.json")
+	t.Setenv("ORG_REGISTRY", path)
+	resetOrgRegistry()
+	t.Cleanup(resetOrgRegistry)
+
+	if reg := loadOrgRegistry(nil); reg != nil {
+		t.Fatal("registry loaded from a missing file")
+	}
+	if err := os.WriteFile(path, []byte("[{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if reg := loadOrgRegistry(nil); reg != nil {
+		t.Fatal("registry loaded from a broken file")
+	}
+
+	// the fixed file is read by the next conversion
+	if err := os.WriteFile(path, []byte(orgRegistryTestJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+	reg := loadOrgRegistry(nil)
+	if reg == nil || len(reg.orgs) != 6 {
+		t.Fatalf("registry %v after the file was fixed, want 6 organizations", reg)
+	}
+	if again := loadOrgRegistry(nil); again != reg {
+		t.Error("unchanged file read again")
+	}
+
+	// a replaced file is read again
+	if err := os.WriteFile(path, []byte(`[{"id": "https://ror.org/05w7r8d80", "name": "Tomsk State University"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	replaced := loadOrgRegistry(nil)
+	if replaced == nil || len(replaced.orgs) != 1 {
+		t.Fatalf("registry %v after the file was replaced, want 1 organization", replaced)
+	}
+
+	// after a reset the same file is read again
+	resetOrgRegistry()
+	if again := loadOrgRegistry(nil); again == replaced || again == nil || len(again.orgs) != 1 {
+		t.Errorf("registry %v after a reset, want a fresh read of 1 organization", again)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// articleAbstracts holds the abstract and keyword blocks of an article in both languages,
+// plus the Russian title and authors taken from the Russian citation line
+type articleAbstracts struct {
+	abstractEn string
+	keywordsEn string
+	abstractRu string
+	keywordsRu string
+	titleRu    string
+	authorsRu  string
+}
+
+// Section headings. Go's \b is ASCII-only, so Cyrillic headings are anchored on a non-letter.
+var (
+	abstractEnRegex = regexp.MustCompile(`(?i)\babstracts?\s*[.:]`)
+	keywordsEnRegex = regexp.MustCompile(`(?i)\bkey\s*words\s*[.:]`)
+	abstractRuRegex = regexp.MustCompile(`(?i)(?:^|[^\p{L}])((?:резюме|аннотация)\s*[.:])`)
+	keywordsRuRegex = regexp.MustCompile(`(?i)(?:^|[^\p{L}])(ключевые\s+слова\s*[.:])`)
+	// Russian citation line: "Иванов И.И., Петров П.П. 2025. Название // Евразиатский энтомол. журнал. Т.24. №3. С.123–130."
+	ruCitationRegex = regexp.MustCompile(`(?m)^[ \t]*([^\n]*?\p{Cyrillic}[^\n]*?)\s+((?:19|20)\d{2})\.\s*([^\n]+?)\s*//`)
+)
+
+type abstractSectionKind int
+
+const (
+	sectionAbstractEn abstractSectionKind = iota
+	sectionKeywordsEn
+	sectionRuHeader
+	sectionAbstractRu
+	sectionKeywordsRu
+)
+
+// abstractSection is a heading found in the article text
+type abstractSection struct {
+	kind         abstractSectionKind
+	headingStart int // where the heading (or the Russian citation line) starts
+	contentStart int // where the section text starts
+}
+
+// extractAbstracts finds English and Russian abstracts and keywords in the article header.
+// Every section runs until the next recognised heading, so the Russian block is never
+// glued to the English keywords.
+func extractAbstracts(art string) articleAbstracts {
+	var sections []abstractSection
+	addFirst := func(kind abstractSectionKind, re *regexp.Regexp) {
+		loc := re.FindStringSubmatchIndex(art)
+		if loc == nil {
+			return
+		}
+		// Use the heading group when the regex has one (Cyrillic headings), else the whole match
+		start, end := loc[0], loc[1]
+		if len(loc) >= 4 && loc[2] != -1 {
+			start, end = loc[2], loc[3]
+		}
+		sections = append(sections, abstractSection{kind: kind, headingStart: start, contentStart: end})
+	}
+
+	addFirst(sectionAbstractEn, abstractEnRegex)
+	addFirst(sectionKeywordsEn, keywordsEnRegex)
+	addFirst(sectionAbstractRu, abstractRuRegex)
+	addFirst(sectionKeywordsRu, keywordsRuRegex)
+
+	var result articleAbstracts
+	if m := ruCitationRegex.FindStringSubmatchIndex(art); m != nil {
+		sections = append(sections, abstractSection{kind: sectionRuHeader, headingStart: m[2], contentStart: m[2]})
+		result.authorsRu = normalizeAuthorsRu(art[m[2]:m[3]])
+		result.titleRu = strings.TrimSpace(art[m[6]:m[7]])
+	}
+
+	sort.Slice(sections, func(i, j int) bool { return sections[i].headingStart < sections[j].headingStart })
+
+	for i, sec := range sections {
+		end := len(art)
+		if i+1 < len(sections) {
+			end = sections[i+1].headingStart
+		}
+		content := strings.TrimSpace(art[sec.contentStart:end])
+
+		switch sec.kind {
+		case sectionAbstractEn:
+			result.abstractEn = content
+		case sectionKeywordsEn:
+			result.keywordsEn = content
+		case sectionAbstractRu:
+			result.abstractRu = content
+		case sectionKeywordsRu:
+			result.keywordsRu = content
+		}
+	}
+
+	return result
+}
+
+// normalizeAuthorsRu strips affiliation numbers and footnote stars from a Russian author line
+func normalizeAuthorsRu(raw string) string {
+	var authors []string
+	for _, auth := range strings.Split(raw, ",") {
+		auth = strings.TrimSpace(authSuffixRegex.ReplaceAllString(auth, ""))
+		if auth != "" {
+			authors = append(authors, auth)
+		}
+	}
+	return strings.Join(authors, ", ")
+}
@@ -0,0 +1,57 @@
+package main
+
+import "testing"
+
+func TestExtractAbstracts(t *testing.T) {
+	tests := []struct {
+		name string
+		art  string
+		want articleAbstracts
+	}{
+		{
+			name: "both languages",
+			art: "Ground beetles of the Altai\nA.B. Ivanova\n" +
+				"ABSTRACT. Eleven species are recorded.\nKey words: Carabidae, Altai.\n" +
+				"Иванова А.Б.1* 2025. Жужелицы Алтая // Евразиатский энтомол. журнал. Т.24. №3. С.1–8.\n" +
+				"Резюме. Приводятся одиннадцать видов.\nКлючевые слова: Carabidae, Алтай.\n",
+			want: articleAbstracts{
+				abstractEn: "Eleven species are recorded.",
+				keywordsEn: "Carabidae, Altai.",
+				abstractRu: "Приводятся одиннадцать видов.",
+				keywordsRu: "Carabidae, Алтай.",
+				titleRu:    "Жужелицы Алтая",
+				authorsRu:  "Иванова А.Б.",
+			},
+		},
+		{
+			// the Russian citation line ends the English keywords even without a Russian abstract
+			name: "Russian citation line without abstract",
+			art: "Abstract: A new species is described.\nKey words. Amara, new species\n" +
+				"Петров С.Д., Сидоров В.В. 2025. Новый вид Amara // Евразиатский энтомол. журнал. Т.24. №3. С.9–12.\n",
+			want: articleAbstracts{
+				abstractEn: "A new species is described.",
+				keywordsEn: "Amara, new species",
+				titleRu:    "Новый вид Amara",
+				authorsRu:  "Петров С.Д., Сидоров В.В.",
+			},
+		},
+		{
+			name: "Аннотация heading",
+			art:  "Аннотация: Описан новый вид.\nКлючевые слова. жужелицы",
+			want: articleAbstracts{abstractRu: "Описан новый вид.", keywordsRu: "жужелицы"},
+		},
+		{
+			// "резюме" inside a word is not a heading
+			name: "no headings",
+			art:  "Obituary\nпредрезюме: text without sections",
+			want: articleAbstracts{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractAbstracts(tt.art); got != tt.want {
+				t.Errorf("extractAbstracts():\n got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Affiliation is an author's affiliation split into its parts
+type Affiliation struct {
+	Department  string
+	Institution string
+	City        string
+	Country     string // canonical English name when it is in the gazetteer, as printed otherwise
+	CountryCode string // ISO 3166-1 alpha-2, "" when the country is not in the gazetteer
+	Raw         string
+}
+
+var (
+	departmentRegex  = regexp.MustCompile(`(?i)\b(faculty|department|dept\.?|laboratory|lab\.|division|chair|section|sector|unit|group)\b|кафедра|лаборатория|отдел|факультет|сектор`)
+	institutionRegex = regexp.MustCompile(`(?i)\b(university|univ|institute|inst|academy|museum|cent(?:re|er)|college|garden|reserve|station|society|agency|survey|school|herbarium)\b|университет|институт|академия|музей|заповедник|станция|центр`)
+	// street addresses and buildings: "Leninskie Gory, 1", "Frunze str. 11", "building 12"
+	streetRegex = regexp.MustCompile(`(?i)\b(str\.|street|st\.|ave\.|avenue|prospekt|pr\.|nab\.|emb\.|road|rd\.|lane|gory|building|bldg\.|box|ул\.|пр\.|наб\.|д\.)|\d`)
+	// "Moscow 119234 Russia", "119234 Moscow", "St Petersburg 199034"
+	postalCodeRegex = regexp.MustCompile(`\b\d{4,6}(?:-\d{3,4})?\b`)
+)
+
+// splitAffiliation splits "Faculty of Biology, Lomonosov Moscow State University,
+// Leninskie Gory 1-12, Moscow 119234 Russia" into department, institution, city and country
+func splitAffiliation(raw string) Affiliation {
+	aff := Affiliation{Raw: raw}
+	parts := splitAffiliationParts(raw)
+	if len(parts) == 0 {
+		return aff
+	}
+
+	// The last part holds the country, often with the city and postal code in front
+	last := parts[len(parts)-1]
+	country, countryLoc := lastCountryIndex(last)
+	if usStateZIPRegex.MatchString(last) {
+		// "Albuquerque, NM 87131": the state and ZIP code stand for the country
+		country, countryLoc = *countryByName["usa"], []int{0, len(last)}
+	}
+	if countryLoc != nil {
+		aff.Country, aff.CountryCode = country.Name(), country.Code
+		last = strings.Trim(last[:countryLoc[0]]+last[countryLoc[1]:], " .")
+		if last == "" {
+			// the country was a part of its own: "..., St Petersburg 199034, Russia"
+			parts = parts[:len(parts)-1]
+			if len(parts) == 0 {
+				return aff
+			}
+			last = parts[len(parts)-1]
+		}
+		// the US state (and ZIP code) between the city and the country is not the city
+		if country.Code == "US" && usStateRegex.MatchString(last) {
+			parts = parts[:len(parts)-1]
+			if len(parts) == 0 {
+				return aff
+			}
+			last = parts[len(parts)-1]
+		}
+	}
+	if loc := postalCodeRegex.FindStringIndex(last); loc != nil {
+		before := strings.TrimSpace(last[:loc[0]])
+		after := strings.TrimSpace(last[loc[1]:])
+		switch {
+		case after != "" && before != "" && countryLoc == nil:
+			aff.City, aff.Country = before, after
+		case before != "":
+			aff.City = before // "Moscow 119234"
+		default:
+			aff.City = after // "119234 Moscow"
+		}
+		parts = parts[:len(parts)-1]
+	} else if countryLoc != nil {
+		// "Moscow, Russia" or "Moscow Russia": what is left is handled as the city below
+		parts[len(parts)-1] = last
+	} else if len(parts) > 1 {
+		aff.Country = last
+		parts = parts[:len(parts)-1]
+	}
+
+	var departments, institutions []string
+	for i, part := range parts {
+		switch {
+		case departmentRegex.MatchString(part) && !institutionRegex.MatchString(part):
+			departments = append(departments, part)
+		case institutionRegex.MatchString(part):
+			institutions = append(institutions, part)
+		case aff.City == "" && i == len(parts)-1 && !streetRegex.MatchString(part):
+			// the part right before the country is the city when it is not an address
+			aff.City = strings.TrimSpace(postalCodeRegex.ReplaceAllString(part, ""))
+		case len(institutions) > 0 && !streetRegex.MatchString(part) && departmentRegex.FindString(part) == "":
+			// parent organisation without a keyword: "Zoological Institute, Russian Academy of Sciences"
+			institutions = append(institutions, part)
+		}
+	}
+
+	aff.Department = strings.Join(departments, ", ")
+	aff.Institution = strings.Join(institutions, ", ")
+	return aff
+}
+
+// isAddressLine reports whether a header line names a country, as affiliation lines do
+func isAddressLine(line string) bool {
+	_, ok := findCountry(line)
+	return ok
+}
+
+// splitAffiliationParts splits on commas outside parentheses and trims a trailing dot
+func splitAffiliationParts(raw string) []string {
+	var parts []string
+	var current strings.Builder
+	depth := 0
+	flush := func() {
+		if part := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(current.String()), ".")); part != "" {
+			parts = append(parts, part)
+		}
+		current.Reset()
+	}
+	for _, r := range raw {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')' && depth > 0:
+			depth--
+		case r == ',' && depth == 0:
+			flush()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	flush()
+	return parts
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	emailRegex = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+	// bare iD or https://orcid.org/ form
+	orcidRegex = regexp.MustCompile(`(?i)(?:https?://(?:www\.)?orcid\.org/)?\b(\d{4}-\d{4}-\d{4}-\d{3}[\dX])\b`)
+	// footnote marker at the start of a contact line: "1 Zoological...", "* Corresponding author", "²"
+	footnoteRegex = regexp.MustCompile(`^\s*(\d+|\*+)`)
+	// markers after an author name in the citation line: "Ivanov I.I.1,2*"
+	authorMarkerRegex = regexp.MustCompile(`\d+|\*`)
+	// words of a line for looking up surnames
+	letterRunRegex = regexp.MustCompile(`\p{L}+`)
+)
+
+// authorContacts holds emails and ORCID iDs aligned with the article's author list
+type authorContacts struct {
+	emails []string
+	orcids []string
+}
+
+// contactAuthor is an author as needed for matching contacts
+type contactAuthor struct {
+	surname string
+	markers map[string]bool // affiliation numbers and "*" from the citation line
+}
+
+// extractContacts finds emails and ORCID iDs in the header lines and attaches each
+// to an author: by surname on the same line, by surname in the email address,
+// by the line's footnote marker, or to the only author. Problems are returned as messages.
+func extractContacts(headerLines []string, authorsRaw []string) (authorContacts, []string) {
+	authors := make([]contactAuthor, len(authorsRaw))
+	for i, raw := range authorsRaw {
+		authors[i] = contactAuthor{
+			surname: authorSurname(authSuffixRegex.ReplaceAllString(raw, "")),
+			markers: make(map[string]bool),
+		}
+		for _, m := range authorMarkerRegex.FindAllString(raw, -1) {
+			authors[i].markers[m] = true
+		}
+	}
+
+	contacts := authorContacts{
+		emails: make([]string, len(authors)),
+		orcids: make([]string, len(authors)),
+	}
+	var problems []string
+
+	assign := func(values []string, kind, value string, idx int) {
+		switch {
+		case idx == -1:
+			problems = append(problems, fmt.Sprintf("%s %s could not be matched to an author", kind, value))
+		case values[idx] == "":
+			values[idx] = value
+		case values[idx] != value:
+			problems = append(problems, fmt.Sprintf("%s %s ignored, %s already has %s", kind, value, authorsRaw[idx], values[idx]))
+		}
+	}
+
+	for _, line := range headerLines {
+		marker := ""
+		if m := footnoteRegex.FindStringSubmatch(line); m != nil {
+			marker = m[1]
+			if strings.HasPrefix(marker, "*") {
+				marker = "*"
+			}
+		}
+
+		for _, loc := range emailRegex.FindAllStringIndex(line, -1) {
+			email := strings.ToLower(strings.TrimRight(line[loc[0]:loc[1]], "."))
+			idx := matchContactAuthor(authors, line[:loc[0]], marker)
+			if idx == -1 {
+				idx = matchEmailLocalPart(authors, email)
+			}
+			assign(contacts.emails, "E-mail", email, idx)
+		}
+
+		for _, m := range orcidRegex.FindAllStringSubmatchIndex(line, -1) {
+			orcid := strings.ToUpper(line[m[2]:m[3]])
+			if !validORCID(orcid) {
+				problems = append(problems, fmt.Sprintf("ORCID %s has an invalid checksum", orcid))
+				continue
+			}
+			assign(contacts.orcids, "ORCID", "https://orcid.org/"+orcid, matchContactAuthor(authors, line[:m[0]], marker))
+		}
+	}
+
+	return contacts, problems
+}
+
+// matchContactAuthor picks the author a contact belongs to from the text before it
+// on the same line and from the line's footnote marker. Returns -1 if ambiguous.
+func matchContactAuthor(authors []contactAuthor, before, marker string) int {
+	// 1. The nearest author surname before the contact
+	best, bestPos := -1, -1
+	for i, a := range authors {
+		if a.surname == "" {
+			continue
+		}
+		if pos := lastWordIndex(before, a.surname); pos > bestPos {
+			best, bestPos = i, pos
+		}
+	}
+	if best != -1 {
+		return best
+	}
+
+	// 2. The only author carrying the line's footnote marker
+	if marker != "" {
+		found := -1
+		for i, a := range authors {
+			if a.markers[marker] {
+				if found != -1 {
+					return -1
+				}
+				found = i
+			}
+		}
+		if found != -1 {
+			return found
+		}
+	}
+
+	// 3. Single-author article
+	if len(authors) == 1 {
+		return 0
+	}
+	return -1
+}
+
+// matchEmailLocalPart matches "ivanov@zin.ru" or "a.ivanov@..." to the author Ivanov
+func matchEmailLocalPart(authors []contactAuthor, email string) int {
+	local, _, _ := strings.Cut(email, "@")
+	found := -1
+	for i, a := range authors {
+		if a.surname != "" && strings.Contains(local, strings.ToLower(a.surname)) {
+			if found != -1 {
+				return -1
+			}
+			found = i
+		}
+	}
+	return found
+}
+
+// lastWordIndex finds the last case-insensitive occurrence of word in s as a whole word
+// ("van der Berg" as consecutive words); returns its byte offset or -1
+func lastWordIndex(s, word string) int {
+	want := letterRunRegex.FindAllString(word, -1)
+	if len(want) == 0 {
+		return -1
+	}
+	runs := letterRunRegex.FindAllStringIndex(s, -1)
+	for i := len(runs) - len(want); i >= 0; i-- {
+		match := true
+		for j, w := range want {
+			if !strings.EqualFold(s[runs[i+j][0]:runs[i+j][1]], w) {
+				match = false
+				break
+			}
+		}
+		if match {
+			return runs[i][0]
+		}
+	}
+	return -1
+}
+
+// authorSurname returns the surname of "Ivanov I.I." or "van der Berg J."
+func authorSurname(author string) string {
+	var words []string
+	for _, w := range strings.Fields(author) {
+		if strings.Contains(w, ".") {
+			break // initials start
+		}
+		words = append(words, w)
+	}
+	return strings.Join(words, " ")
+}
+
+// validORCID checks the ISO 7064 MOD 11-2 check digit of an ORCID iD (0000-0002-1825-0097)
+func validORCID(orcid string) bool {
+	digits := strings.ReplaceAll(orcid, "-", "")
+	if len(digits) != 16 {
+		return false
+	}
+	total := 0
+	for _, r := range digits[:15] {
+		if r < '0' || r > '9' {
+			return false
+		}
+		total = (total + int(r-'0')) * 2
+	}
+	check := (12 - total%11) % 11
+	want := byte('0' + check)
+	if check == 10 {
+		want = 'X'
+	}
+	return digits[15] == want
+}
@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+func TestValidORCID(t *testing.T) {
+	tests := []struct {
+		orcid string
+		want  bool
+	}{
+		{"0000-0002-1825-0097", true},
+		{"0000-0001-5109-3700", true},
+		{"0000-0002-1694-233X", true}, // check digit 10
+		{"0000000218250097", true},
+		{"0000-0002-1825-0098", false},
+		{"0000-0002-1694-2330", false},
+		{"0000-0002-1694-233x", false}, // the check character is a capital X
+		{"0000-0002-1825-009", false},
+		{"0000-000A-1825-0097", false},
+		{"X000-0002-1825-0097", false},
+	}
+	for _, tt := range tests {
+		if got := validORCID(tt.orcid); got != tt.want {
+			t.Errorf("validORCID(%q) = %v, want %v", tt.orcid, got, tt.want)
+		}
+	}
+}
+
+func TestLastWordIndex(t *testing.T) {
+	tests := []struct {
+		s, word string
+		want    int
+	}{
+		{"Petrov C.D., Ivanova A.B.: a.ivanova@example.org", "Ivanova", 29}, // the address, the last one
+		{"IVANOVA A.B. and Ivanova-Smith", "ivanova", 17},
+		{"Ivanovakis G.", "Ivanova", -1},
+		{"J. van der Berg, Leiden", "van der Berg", 3},
+		{"Сидоров Е.Ф., e.sidorov@example.org", "Сидоров", 0},
+		{"no surname here", "Petrov", -1},
+		{"Petrov", "", -1},
+	}
+	for _, tt := range tests {
+		if got := lastWordIndex(tt.s, tt.word); got != tt.want {
+			t.Errorf("lastWordIndex(%q, %q) = %d, want %d", tt.s, tt.word, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// doiLineRegex finds lines that carry a DOI label or link
+	doiLineRegex = regexp.MustCompile(`(?i)\bdoi\b`)
+	// doiCoreRegex matches the DOI itself: "10." + registrant + "/" + suffix
+	doiCoreRegex = regexp.MustCompile(`(?i)\b10\.\d{4,9}/[^\s"<>]+`)
+	// bareJournalDOIRegex matches our DOI suffix written without the prefix: "euroasentj.24.03.02"
+	bareJournalDOIRegex = regexp.MustCompile(`(?i)\b([a-z]+)\.(\d+\.\d+\.\d+)\b`)
+	// journalDOIRegex is the registry pattern: 10.15298/<journal>.<volume>.<issue>.<article>
+	journalDOIRegex = regexp.MustCompile(`^` + regexp.QuoteMeta(journalDOIPrefix) + `/([a-z]+)\.(\d+)\.(\d+)\.(\d+)$`)
+)
+
+// ParsedDOI holds the components of one of our journals' DOIs
+type ParsedDOI struct {
+	Journal JournalEntry
+	Volume  int
+	Issue   int
+	Article int
+}
+
+// normalizeDOI extracts a DOI from text such as "DOI: 10.15298/...", "doi.10.15298/..."
+// or "https://doi.org/10.15298/...". The result is lowercase (DOIs are case-insensitive)
+// without URL, label or trailing punctuation. A suffix of one of our journals without
+// the prefix ("DOI: euroasentj.24.03.02") gets journalDOIPrefix. Returns "" if there is no DOI.
+func normalizeDOI(s string) string {
+	match := doiCoreRegex.FindString(s)
+	if match == "" {
+		for _, m := range bareJournalDOIRegex.FindAllStringSubmatch(s, -1) {
+			if _, ok := journalByDOICode(strings.ToLower(m[1])); ok {
+				return strings.ToLower(journalDOIPrefix + "/" + m[0])
+			}
+		}
+		return ""
+	}
+	match = strings.TrimRight(match, ".,;:)]}'’”")
+	return strings.ToLower(match)
+}
+
+// parseJournalDOI validates a normalized DOI against the journal registry pattern
+func parseJournalDOI(doi string) (ParsedDOI, error) {
+	m := journalDOIRegex.FindStringSubmatch(doi)
+	if m == nil {
+		return ParsedDOI{}, fmt.Errorf("DOI %q does not match %s/journal.volume.issue.article", doi, journalDOIPrefix)
+	}
+
+	journal, ok := journalByDOICode(m[1])
+	if !ok {
+		return ParsedDOI{}, fmt.Errorf("unknown journal %q in DOI %q", m[1], doi)
+	}
+
+	volume, _ := strconv.Atoi(m[2])
+	issue, _ := strconv.Atoi(m[3])
+	article, _ := strconv.Atoi(m[4])
+	return ParsedDOI{Journal: journal, Volume: volume, Issue: issue, Article: article}, nil
+}
+
+// checkDOISequence validates the DOIs of an issue in document order:
+// registry pattern, same journal/volume/issue, consecutive article numbers and duplicates.
+// Returned messages are keyed by 1-based article number.
+func checkDOISequence(dois []string) map[int][]string {
+	problems := make(map[int][]string)
+	seen := make(map[string]int, len(dois))
+	var first, prev *ParsedDOI
+
+	for i, doi := range dois {
+		artNum := i + 1
+		if doi == "" {
+			continue
+		}
+
+		if dup, ok := seen[doi]; ok {
+			problems[artNum] = append(problems[artNum], fmt.Sprintf("DOI %s duplicates article %d", doi, dup))
+			continue
+		}
+		seen[doi] = artNum
+
+		parsed, err := parseJournalDOI(doi)
+		if err != nil {
+			problems[artNum] = append(problems[artNum], err.Error())
+			continue
+		}
+
+		if first == nil {
+			first = &parsed
+		} else if parsed.Journal.DOICode != first.Journal.DOICode || parsed.Volume != first.Volume || parsed.Issue != first.Issue {
+			problems[artNum] = append(problems[artNum], fmt.Sprintf(
+				"DOI %s points to %s vol. %d no. %d, but the issue is %s vol. %d no. %d",
+				doi, parsed.Journal.Code, parsed.Volume, parsed.Issue, first.Journal.Code, first.Volume, first.Issue))
+		}
+
+		if prev != nil && parsed.Article != prev.Article+1 {
+			problems[artNum] = append(problems[artNum], fmt.Sprintf(
+				"DOI %s has article number %d, expected %d after the previous article",
+				doi, parsed.Article, prev.Article+1))
+		}
+		prev = &parsed
+	}
+
+	return problems
+}
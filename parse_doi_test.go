@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestNormalizeDOI(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"DOI: 10.15298/euroasentj.24.03.02", "10.15298/euroasentj.24.03.02"},
+		{"doi.10.15298/rusentj.34.3.01", "10.15298/rusentj.34.3.01"},
+		{"https://doi.org/10.15298/invertzool.22.3.01.", "10.15298/invertzool.22.3.01"},
+		{"DOI 10.15298/ArthSel.34.3.01;", "10.15298/arthsel.34.3.01"},
+		{"(doi:10.1007/s00114-019-1612-3)", "10.1007/s00114-019-1612-3"},
+		// our journals' suffix without the prefix
+		{"DOI: euroasentj.24.03.02", "10.15298/euroasentj.24.03.02"},
+		{"doi.invertzool.22.3.01", "10.15298/invertzool.22.3.01"},
+		{"DOI: EuroasEntJ.24.03.02.", "10.15298/euroasentj.24.03.02"},
+		// suffixes of other journals are not DOIs
+		{"DOI: otherj.24.03.02", ""},
+		{"Vol.24. No.3. P.201-215", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeDOI(tt.in); got != tt.want {
+			t.Errorf("normalizeDOI(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// articleCitation is the article's own citation line, the first line of its header:
+// "Ivanov I.I.1, Petrov P.P.2* 2025. Title // Euroasian Entomological Journal. Vol.24. No.3. P.123–130."
+type articleCitation struct {
+	authorsRaw string // authors with affiliation numbers and footnote stars
+	year       int
+	title      string
+	journal    string // journal title as printed
+	volume     int    // 0 if not found
+	issue      int    // 0 if not found
+	meta       string // everything after "//"
+}
+
+var (
+	// authors, year, title and meta: the year must be a standalone "YYYY." followed by a space,
+	// so postal codes or years inside the title do not split the line
+	citationLineRegex = regexp.MustCompile(`^(.+?)\s+((?:19|20)\d{2})[a-z]?\.\s+(.+?)\s*//\s*(.+)$`)
+	// Go's \b is ASCII-only, so Cyrillic labels are anchored on a non-letter
+	citationVolRegex = regexp.MustCompile(`(?:\bVol|(?:^|[^\p{L}])Т)\.\s*(\d+)`)
+	citationNoRegex  = regexp.MustCompile(`(?:\bNo|\bNr|№|(?:^|[^\p{L}])Вып)\.?\s*(\d+)`)
+	// journal title ends at the first "Vol."/"Т." label
+	citationJournalRegex = regexp.MustCompile(`^(.*?)[.,]?\s*(?:\bVol|(?:^|[^\p{L}])Т)\.`)
+	cyrillicRegex        = regexp.MustCompile(`\p{Cyrillic}`)
+	// legacy header split: the first four-digit number
+	legacyYearRegex = regexp.MustCompile(`\d\d\d\d`)
+)
+
+// parseCitationLine parses one header line in the journal's citation format
+func parseCitationLine(line string) (articleCitation, bool) {
+	m := citationLineRegex.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return articleCitation{}, false
+	}
+
+	c := articleCitation{
+		authorsRaw: strings.TrimSpace(m[1]),
+		title:      strings.TrimSpace(m[3]),
+		meta:       strings.TrimSpace(m[4]),
+	}
+	c.year, _ = strconv.Atoi(m[2])
+	fillCitationMeta(&c)
+
+	// A citation line always names the volume; this rejects lines that merely contain "//"
+	if c.volume == 0 {
+		return articleCitation{}, false
+	}
+	return c, true
+}
+
+func fillCitationMeta(c *articleCitation) {
+	if vm := citationVolRegex.FindStringSubmatch(c.meta); vm != nil {
+		c.volume, _ = strconv.Atoi(vm[1])
+	}
+	if nm := citationNoRegex.FindStringSubmatch(c.meta); nm != nil {
+		c.issue, _ = strconv.Atoi(nm[1])
+	}
+	if jm := citationJournalRegex.FindStringSubmatch(c.meta); jm != nil {
+		c.journal = strings.TrimSpace(jm[1])
+	}
+}
+
+// findArticleCitation returns the first header line in citation format and its index
+func findArticleCitation(lines []string) (articleCitation, int, bool) {
+	for i, line := range lines {
+		if c, ok := parseCitationLine(line); ok {
+			return c, i, true
+		}
+	}
+	return articleCitation{}, 0, false
+}
+
+// parseHeaderLegacy is the old split on the first four-digit number, kept as a
+// fallback for headers that do not follow the citation format
+func parseHeaderLegacy(art string) (articleCitation, bool) {
+	parts := legacyYearRegex.Split(art, 2)
+	if len(parts) < 2 {
+		return articleCitation{}, false
+	}
+
+	titleAndMeta := parts[1]
+	titleMeta := strings.SplitN(titleAndMeta, "//", 2)
+	// If there's no "//" in the string, split by "/"
+	if len(titleMeta) == 1 {
+		titleMeta = strings.SplitN(titleAndMeta, "/", 2)
+	}
+	if len(titleMeta) < 2 {
+		return articleCitation{}, false
+	}
+
+	c := articleCitation{
+		authorsRaw: parts[0],
+		title:      strings.TrimSpace(strings.TrimPrefix(titleMeta[0], ".")),
+		meta:       titleMeta[1],
+	}
+	c.year, _ = strconv.Atoi(legacyYearRegex.FindString(art))
+	fillCitationMeta(&c)
+	return c, true
+}
+
+// checkAgainstDOI compares the citation's volume and issue with the article's DOI
+func (c articleCitation) checkAgainstDOI(doi string) []string {
+	parsed, err := parseJournalDOI(doi)
+	if err != nil {
+		return nil // reported by checkDOISequence
+	}
+
+	var problems []string
+	if c.volume != 0 && c.volume != parsed.Volume {
+		problems = append(problems, fmt.Sprintf("citation line says Vol.%d, DOI %s says %d", c.volume, doi, parsed.Volume))
+	}
+	if c.issue != 0 && c.issue != parsed.Issue {
+		problems = append(problems, fmt.Sprintf("citation line says No.%d, DOI %s says %d", c.issue, doi, parsed.Issue))
+	}
+	// Only English titles are in the registry
+	if c.journal != "" && !cyrillicRegex.MatchString(c.journal) && !strings.EqualFold(normalizeJournalTitle(c.journal), normalizeJournalTitle(parsed.Journal.Name)) {
+		problems = append(problems, fmt.Sprintf("citation line names %q, DOI belongs to %q", c.journal, parsed.Journal.Name))
+	}
+	return problems
+}
+
+// checkAgainstJournalInfo compares the citation's volume and issue with the issue page on the website
+func (c articleCitation) checkAgainstJournalInfo(info JournalInfo) []string {
+	var problems []string
+	if c.volume != 0 && strconv.Itoa(c.volume) != info.Volume {
+		problems = append(problems, fmt.Sprintf("citation line says Vol.%d, journal website issue is Volume %s", c.volume, info.Volume))
+	}
+	if c.issue != 0 && strconv.Itoa(c.issue) != info.Issue {
+		problems = append(problems, fmt.Sprintf("citation line says No.%d, journal website issue is Number %s", c.issue, info.Issue))
+	}
+	return problems
+}
+
+func normalizeJournalTitle(s string) string {
+	return strings.Join(strings.Fields(strings.TrimRight(s, ".")), " ")
+}
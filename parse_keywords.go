@@ -0,0 +1,122 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Abbreviations that end with a period inside a keyword and must not split it:
+// taxonomic ("sp. n.", "s. str.", "cf.") and common ones ("St.").
+// The value marks abbreviations that can close a term ("Carabus altaicus sp. n. Altai"):
+// those split when the next word is capitalized.
+var keywordAbbreviations = map[string]bool{
+	"sp": true, "spp": true, "n": true, "nov": true, "str": true, "l": true, "al": true,
+	"ssp": false, "subsp": false, "gen": false, "s": false, "var": false, "f": false,
+	"cf": false, "aff": false, "st": false, "comb": false, "stat": false, "syn": false,
+	"et": false, "mt": false, "mts": false,
+}
+
+var keywordSpaceRegex = regexp.MustCompile(`\s+`)
+
+// splitKeywords splits a raw keyword paragraph into individual terms.
+//
+// Separators are commas, semicolons and sentence periods ("Coleoptera. Carabidae.");
+// a dash is not one, it joins the parts of a term ("хищник — жертва").
+// Periods after initials and taxonomic abbreviations ("C. elegans", "sp. n.") do not
+// split, so binomials and taxon names stay whole, and their case is preserved
+// (the text comes from docconv without formatting, so italics cannot be carried over).
+// Terms are whitespace-normalized, trailing dots are dropped and duplicates
+// (case-insensitive) are removed, keeping the first spelling.
+func splitKeywords(raw string) []string {
+	raw = keywordSpaceRegex.ReplaceAllString(strings.ReplaceAll(raw, "\u00a0", " "), " ")
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+
+	var terms []string
+	var current strings.Builder
+	depth := 0 // parentheses/brackets depth: "Carabus (Coleoptera, Carabidae)" is one term
+	runes := []rune(raw)
+
+	flush := func() {
+		if term := cleanKeyword(current.String()); term != "" {
+			terms = append(terms, term)
+		}
+		current.Reset()
+	}
+
+	for i, r := range runes {
+		switch {
+		case r == '(' || r == '[':
+			depth++
+		case (r == ')' || r == ']') && depth > 0:
+			depth--
+		case depth == 0 && (r == ',' || r == ';'):
+			flush()
+			continue
+		case depth == 0 && r == '.' && isKeywordSentenceEnd(runes, i, current.String()):
+			// keep the period: cleanKeyword drops it unless it closes an abbreviation
+			current.WriteRune(r)
+			flush()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	flush()
+
+	return dedupKeywords(terms)
+}
+
+// isKeywordSentenceEnd reports whether the period at runes[i] ends a keyword
+func isKeywordSentenceEnd(runes []rune, i int, term string) bool {
+	// Period must be followed by a space or the end of the text ("3.5" is a number)
+	if i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+		return false
+	}
+	fields := strings.Fields(term)
+	if len(fields) == 0 {
+		return false
+	}
+	lastWord := fields[len(fields)-1]
+	// Initials in binomials ("D. melanogaster") and abbreviations ("sp. n.")
+	if len([]rune(lastWord)) == 1 && unicode.IsUpper([]rune(lastWord)[0]) {
+		return false
+	}
+	terminal, isAbbr := keywordAbbreviations[strings.ToLower(lastWord)]
+	if !isAbbr {
+		return true
+	}
+	return terminal && i+2 < len(runes) && unicode.IsUpper(runes[i+2])
+}
+
+// cleanKeyword trims spaces and a trailing dot, unless the dot belongs to an abbreviation
+func cleanKeyword(term string) string {
+	term = strings.TrimSpace(term)
+	if strings.HasSuffix(term, ".") {
+		fields := strings.Fields(strings.TrimSuffix(term, "."))
+		isAbbr := false
+		if len(fields) > 0 {
+			_, isAbbr = keywordAbbreviations[strings.ToLower(fields[len(fields)-1])]
+		}
+		if !isAbbr {
+			term = strings.TrimSpace(strings.TrimSuffix(term, "."))
+		}
+	}
+	return term
+}
+
+func dedupKeywords(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	result := make([]string, 0, len(terms))
+	for _, term := range terms {
+		key := strings.ToLower(term)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, term)
+	}
+	return result
+}
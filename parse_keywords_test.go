@@ -0,0 +1,31 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitKeywords(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want []string
+	}{
+		{"Carabidae, Altai, new records", []string{"Carabidae", "Altai", "new records"}},
+		{"Carabidae; Altai;  new records;", []string{"Carabidae", "Altai", "new records"}},
+		{"Coleoptera. Carabidae. Altai.", []string{"Coleoptera", "Carabidae", "Altai"}},
+		{"Carabidae, Altai.", []string{"Carabidae", "Altai"}},
+		{"жужелицы, Алтай; новые находки.", []string{"жужелицы", "Алтай", "новые находки"}},
+		// a dash joins the parts of one term
+		{"хищник — жертва, Carabidae", []string{"хищник — жертва", "Carabidae"}},
+		{"D. melanogaster, Amara sp. n., Carabus s. str.", []string{"D. melanogaster", "Amara sp. n.", "Carabus s. str."}},
+		{"Carabus altaicus sp. n. Altai", []string{"Carabus altaicus sp. n.", "Altai"}},
+		{"Carabus (Coleoptera, Carabidae), temperature 3.5 °C", []string{"Carabus (Coleoptera, Carabidae)", "temperature 3.5 °C"}},
+		{"Altai, altai, ALTAI", []string{"Altai"}},
+		{"  ", nil},
+	}
+	for _, tt := range tests {
+		if got := splitKeywords(tt.raw); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitKeywords(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PageRange is the location of an article in its issue
+type PageRange struct {
+	First         int    // first page (0 if the article only has an article number)
+	Last          int    // last page, equal to First for single-page items
+	ArticleNumber string // electronic article number, e.g. "e123"
+	Supplement    bool   // supplement pagination, e.g. "S12–S20"
+	Roman         bool   // front matter paginated in roman numerals, e.g. "xii–xv"
+}
+
+// PageFormat controls how page ranges are displayed for a journal.
+// page_format in the journal's state file overrides the registry's.
+type PageFormat struct {
+	Pad       int    `yaml:"pad"`       // zero-pad page numbers to this width (0 = no padding)
+	Separator string `yaml:"separator"` // between first and last page
+}
+
+// defaultPageFormat matches the historical "%03d-%03d" output.
+// Supplement pages ("S12") and roman numerals are never padded.
+var defaultPageFormat = PageFormat{Pad: 3, Separator: "-"}
+
+var (
+	// pagesLabelRegex finds the page label in citation meta: "P.123–130", "pp. 5-9", "С.12–20", "Art. e123"
+	pagesLabelRegex = regexp.MustCompile(`(?:\b[Pp]{1,2}\.|С\.|\bArt(?:icle)?\.?)\s*`)
+	// page token forms, tried in order after the label
+	articleNumberRegex = regexp.MustCompile(`(?i)^(e\d+)\b`)
+	supplementRegex    = regexp.MustCompile(`^S(\d+)(?:\s*[-–—]\s*S?(\d+))?\b`)
+	romanPagesRegex    = regexp.MustCompile(`(?i)^([ivxlcdm]+)(?:\s*[-–—]\s*([ivxlcdm]+))?\b`)
+	arabicPagesRegex   = regexp.MustCompile(`^(\d+)(?:\s*[-–—]\s*(\d+))?\b`)
+	// unlabelled range anywhere in the text, used when there is no page label
+	bareRangeRegex = regexp.MustCompile(`\b\d+\s*[-–—]\s*\d+\b`)
+)
+
+// parsePages extracts the article's pages from citation meta
+// ("Euroasian Entomological Journal. Vol.24. No.3. P.123–130.")
+func parsePages(meta string) (PageRange, bool) {
+	for _, loc := range pagesLabelRegex.FindAllStringIndex(meta, -1) {
+		if pr, ok := parsePageToken(meta[loc[1]:]); ok {
+			return pr, true
+		}
+	}
+	// No label: fall back to the last bare range, the old behaviour
+	if locs := bareRangeRegex.FindAllStringIndex(meta, -1); len(locs) > 0 {
+		return parsePageToken(meta[locs[len(locs)-1][0]:])
+	}
+	return PageRange{}, false
+}
+
+// parsePageToken parses pages at the start of s
+func parsePageToken(s string) (PageRange, bool) {
+	s = strings.TrimSpace(s)
+
+	if m := articleNumberRegex.FindStringSubmatch(s); m != nil {
+		return PageRange{ArticleNumber: strings.ToLower(m[1])}, true
+	}
+
+	if m := supplementRegex.FindStringSubmatch(s); m != nil {
+		first, last := atoiRange(m[1], m[2])
+		return PageRange{First: first, Last: last, Supplement: true}, true
+	}
+
+	if m := arabicPagesRegex.FindStringSubmatch(s); m != nil {
+		first, last := atoiRange(m[1], m[2])
+		return PageRange{First: first, Last: last}, true
+	}
+
+	if m := romanPagesRegex.FindStringSubmatch(s); m != nil {
+		first := fromRoman(m[1])
+		last := first
+		if m[2] != "" {
+			last = fromRoman(m[2])
+		}
+		if first > 0 && last > 0 {
+			return PageRange{First: first, Last: last, Roman: true}, true
+		}
+	}
+
+	return PageRange{}, false
+}
+
+func atoiRange(first, last string) (int, int) {
+	f, _ := strconv.Atoi(first)
+	l := f
+	if last != "" {
+		l, _ = strconv.Atoi(last)
+	}
+	return f, l
+}
+
+// Format renders the range using the journal's page format
+func (pr PageRange) Format(pf PageFormat) string {
+	if pr.ArticleNumber != "" {
+		return pr.ArticleNumber
+	}
+
+	page := func(n int) string {
+		switch {
+		case pr.Roman:
+			return toRoman(n)
+		case pr.Supplement:
+			return fmt.Sprintf("S%d", n)
+		default:
+			return fmt.Sprintf("%0*d", pf.Pad, n)
+		}
+	}
+
+	if pr.First == pr.Last {
+		return page(pr.First)
+	}
+	return page(pr.First) + pf.Separator + page(pr.Last)
+}
+
+// Validate checks the range itself and its contiguity with the previous article's range
+func (pr PageRange) Validate(prev *PageRange) []string {
+	var problems []string
+	if pr.ArticleNumber != "" {
+		return nil
+	}
+	if pr.First > pr.Last {
+		problems = append(problems, fmt.Sprintf("first page %d is after last page %d", pr.First, pr.Last))
+	}
+	if prev == nil || prev.ArticleNumber != "" || prev.Supplement != pr.Supplement || prev.Roman != pr.Roman {
+		return problems
+	}
+	switch {
+	case pr.First <= prev.Last:
+		problems = append(problems, fmt.Sprintf("starts on page %d, overlapping the previous article (ends on %d)", pr.First, prev.Last))
+	case pr.First > prev.Last+1:
+		problems = append(problems, fmt.Sprintf("starts on page %d, leaving a gap after the previous article (ends on %d)", pr.First, prev.Last))
+	}
+	return problems
+}
+
+var romanValues = []struct {
+	value  int
+	symbol string
+}{
+	{1000, "m"}, {900, "cm"}, {500, "d"}, {400, "cd"}, {100, "c"}, {90, "xc"},
+	{50, "l"}, {40, "xl"}, {10, "x"}, {9, "ix"}, {5, "v"}, {4, "iv"}, {1, "i"},
+}
+
+// fromRoman converts a roman numeral to an integer, 0 if it is not valid
+func fromRoman(s string) int {
+	s = strings.ToLower(s)
+	n := 0
+	for _, rv := range romanValues {
+		for strings.HasPrefix(s, rv.symbol) {
+			n += rv.value
+			s = s[len(rv.symbol):]
+		}
+	}
+	if s != "" {
+		return 0
+	}
+	return n
+}
+
+// toRoman converts a positive integer to a lowercase roman numeral
+func toRoman(n int) string {
+	var sb strings.Builder
+	for _, rv := range romanValues {
+		for n >= rv.value {
+			sb.WriteString(rv.symbol)
+			n -= rv.value
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePages(t *testing.T) {
+	tests := []struct {
+		meta string
+		want PageRange
+		ok   bool
+	}{
+		{"Euroasian Entomological Journal. Vol.24. No.3. P.123–130.", PageRange{First: 123, Last: 130}, true},
+		{"Vol.24. No.3. pp. 5-9", PageRange{First: 5, Last: 9}, true},
+		{"Евразиатский энтомол. журнал. Т.24. №3. С.12–20.", PageRange{First: 12, Last: 20}, true},
+		{"Vol.24. P.7.", PageRange{First: 7, Last: 7}, true},
+		{"Vol.24. Art. e123.", PageRange{ArticleNumber: "e123"}, true},
+		{"Vol.24. P.E45.", PageRange{ArticleNumber: "e45"}, true},
+		{"Vol.24. Suppl.1. P.S12–S20.", PageRange{First: 12, Last: 20, Supplement: true}, true},
+		{"Vol.24. P.S5.", PageRange{First: 5, Last: 5, Supplement: true}, true},
+		{"Vol.24. P.xii–xv.", PageRange{First: 12, Last: 15, Roman: true}, true},
+		{"Vol.24. P.IV.", PageRange{First: 4, Last: 4, Roman: true}, true},
+		// no label: the last bare range
+		{"Vol.24. No.3. 121-128", PageRange{First: 121, Last: 128}, true},
+		{"Vol.24. No.3.", PageRange{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parsePages(tt.meta)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parsePages(%q) = %+v, %v; want %+v, %v", tt.meta, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestPageRangeFormat(t *testing.T) {
+	enDash := PageFormat{Separator: "–"}
+	tests := []struct {
+		pages PageRange
+		pf    PageFormat
+		want  string
+	}{
+		{PageRange{First: 5, Last: 9}, defaultPageFormat, "005-009"},
+		{PageRange{First: 123, Last: 1130}, defaultPageFormat, "123-1130"},
+		{PageRange{First: 7, Last: 7}, defaultPageFormat, "007"},
+		{PageRange{First: 5, Last: 9}, enDash, "5–9"},
+		// supplement pages and roman numerals are not padded
+		{PageRange{First: 12, Last: 20, Supplement: true}, defaultPageFormat, "S12-S20"},
+		{PageRange{First: 5, Last: 5, Supplement: true}, defaultPageFormat, "S5"},
+		{PageRange{First: 12, Last: 15, Roman: true}, defaultPageFormat, "xii-xv"},
+		{PageRange{ArticleNumber: "e123"}, defaultPageFormat, "e123"},
+	}
+	for _, tt := range tests {
+		if got := tt.pages.Format(tt.pf); got != tt.want {
+			t.Errorf("%+v.Format(%+v) = %q, want %q", tt.pages, tt.pf, got, tt.want)
+		}
+	}
+}
+
+func TestPageRangeValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		pages PageRange
+		prev  *PageRange
+		want  string // part of the only problem, "" for none
+	}{
+		{"first article", PageRange{First: 1, Last: 8}, nil, ""},
+		{"contiguous", PageRange{First: 9, Last: 16}, &PageRange{First: 1, Last: 8}, ""},
+		{"overlap", PageRange{First: 8, Last: 16}, &PageRange{First: 1, Last: 8}, "overlapping the previous article"},
+		{"gap", PageRange{First: 11, Last: 16}, &PageRange{First: 1, Last: 8}, "leaving a gap"},
+		{"reversed", PageRange{First: 20, Last: 12}, nil, "first page 20 is after last page 12"},
+		{"supplement after regular pages", PageRange{First: 1, Last: 4, Supplement: true}, &PageRange{First: 1, Last: 8}, ""},
+		{"contiguous supplement", PageRange{First: 5, Last: 9, Supplement: true}, &PageRange{First: 1, Last: 4, Supplement: true}, ""},
+		{"roman front matter", PageRange{First: 1, Last: 8}, &PageRange{First: 1, Last: 12, Roman: true}, ""},
+		{"article number", PageRange{ArticleNumber: "e2"}, &PageRange{First: 1, Last: 8}, ""},
+		{"after an article number", PageRange{First: 30, Last: 40}, &PageRange{ArticleNumber: "e1"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := tt.pages.Validate(tt.prev)
+			switch {
+			case tt.want == "" && len(problems) > 0:
+				t.Errorf("problems %q, want none", problems)
+			case tt.want != "" && (len(problems) != 1 || !strings.Contains(problems[0], tt.want)):
+				t.Errorf("problems %q, want one with %q", problems, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfiguredJournalPageFormat(t *testing.T) {
+	journal := configuredJournal("EEJ", &JournalState{PageFormat: &PageFormat{}})
+	if journal.Pages != (PageFormat{Separator: "-"}) {
+		t.Errorf("page_format with no settings gives %+v, want no padding and the default separator", journal.Pages)
+	}
+	if journal := configuredJournal("EEJ", &JournalState{}); journal.Pages != defaultPageFormat {
+		t.Errorf("no page_format gives %+v, want the registry's %+v", journal.Pages, defaultPageFormat)
+	}
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"regexp"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 /*
@@ -63,6 +65,23 @@ REFERENCE STRUCTURE TYPES HANDLED:
 5. Institutional (No authors):
    Format: Title. Year. Edition. City: Publisher.
    Example: A manual of acarology. 2009. 3rd edition. Texas: Press.
+   A sentence of three or more words before the year is the title and all
+   after the year is meta; shorter text is an institution and goes to meta.
+   Function: isTitleBeforeYear()
+
+7. CYRILLIC REFERENCES
+   - Russian abbreviations (Т., Вып., С., М., СПб.) and journal-title markers
+     (Известия, Труды, Вестник) are used when the reference is in Cyrillic
+   - "М.: Наука", "СПб.: Изд-во «Наука»" are found by the Unicode-aware city:publisher pattern
+   - Capital letters and lowercase starts are checked per rune, not per byte
+   - "[In Russian]" notes are removed before parsing and kept as the language;
+     a bracketed translated title is kept apart: "Ekologiya [Ecology]"
+   - Functions: referenceMarkers(), stripLanguageNote(), splitTranslatedTitle()
+
+8. CONFIDENCE
+   - splitTitleMeta reports which heuristic decided the split
+   - scoreReference() turns it into a 0..1 score, lowered for missing parts
+   - Low-confidence rows are highlighted on the References sheet
 
 KEY PARAMETERS (tunable):
 - minTitleLength = 15        (chars before allowing period-split)
@@ -75,17 +94,26 @@ KEY PARAMETERS (tunable):
 // we will store only the first 4 digits as the publication year
 var yearRe = regexp.MustCompile(`\b(\d{4})([a-z])?(?:\s*[-–—]\s*\d{4})?\b`)
 
-// detect likely author block (initials like "Z.M." or commas between names)
-var initialRe = regexp.MustCompile(`[\p{L}]\.`) // letter followed by dot (unicode aware)
+// detect likely author block (initials like "Z.M." or commas between names):
+// a capital letter alone before the dot, so "A manual of acarology." has none
+var initialRe = regexp.MustCompile(`(?:^|[^\p{L}])\p{Lu}\.`)
 
 // editor patterns - stronger signal than general markers, checked early
-// Matches: "// Author (ed.):" or "// Author (eds.)." or "// Author (Ed):"
-var editorPatternRe = regexp.MustCompile(`(?i)//\s*[^/]+\s*\(\s*e(?:d|ds)\.?\s*\)\s*[:;.]`)
+// Matches: "// Author (ed.):" or "// Author (eds.)." or "// Author (Ed):" or "// Иванов И.И. (ред.):"
+var editorPatternRe = regexp.MustCompile(`(?i)//\s*[^/]+\s*\(\s*(?:e(?:d|ds)|(?:отв\.\s*)?ред)\.?\s*\)\s*[:;.]`)
+
+// "[In Russian]", "[in Russian with English summary]", "[На русском языке]"
+var languageNoteRe = regexp.MustCompile(`(?i)\[\s*(?:in\s+russian|на\s+русском\s+языке)[^\]]*\]\.?`)
+
+// "Ekologiya [Ecology]": a transliterated title followed by its translation
+var translatedTitleRe = regexp.MustCompile(`^(.*\S)\s*\[([^\[\]]+)\]\.?$`)
 
 // Common abbreviations that should NOT trigger title/meta splits
 var abbreviations = []string{
 	"St", "Vol", "No", "Nos", "Pt", "P", "S", "Bd", "Ed", "Eds",
 	"T", "Ch", "Art", "Ph", "Dr", "Mr", "Mrs", "Ms",
+	// Russian: volume, issue, pages, Moscow, Leningrad, St Petersburg, editor, publishing house
+	"Т", "Вып", "С", "М", "Л", "СПб", "Ред", "Отв", "Изд", "Гос", "Ин-т", "Ун-т", "Сб", "Зап",
 }
 
 // Tuning parameters for parsing
@@ -123,6 +151,21 @@ var publicationMarkers = []string{
 	// which detects the pattern "CapitalizedCity: CapitalizedPublisher" contextually
 }
 
+// russianPublicationMarkers are added to publicationMarkers for Cyrillic references
+var russianPublicationMarkers = []string{
+	"режим доступа", "дата обращения", "url:",
+	"труды", "известия", "вестник", "журнал", "журн.", "бюллетень", "бюл.",
+	"записки", "сборник", "материалы", "тезисы", "обозрение", "обозр.",
+}
+
+// referenceMarkers returns the publication markers for the reference's script
+func referenceMarkers(s string) []string {
+	if !cyrillicRegex.MatchString(s) {
+		return publicationMarkers
+	}
+	return append(append([]string{}, russianPublicationMarkers...), publicationMarkers...)
+}
+
 func pickYearIndex(ref string) (int, int, string) {
 	loc := yearRe.FindStringIndex(ref)
 	if loc == nil {
@@ -148,10 +191,20 @@ func isLikelyAuthorBlock(s string) bool {
 	if strings.Contains(s, ",") {
 		return true
 	}
+	// a single word with a dot is a corporate author: "GBIF.org"
+	if !strings.Contains(s, " ") && strings.Contains(strings.TrimSuffix(s, "."), ".") {
+		return true
+	}
 	// otherwise treat as not author (institution/title)
 	return false
 }
 
+// isTitleBeforeYear reports whether the text before the year is a title, not an
+// institution: a sentence of several words ("A manual of acarology.")
+func isTitleBeforeYear(s string) bool {
+	return strings.HasSuffix(s, ".") && len(strings.Fields(s)) >= 3
+}
+
 func findFirstOfMarkers(s string, markers []string) int {
 	return findFirstOfMarkersFrom(s, markers, 0)
 }
@@ -197,9 +250,12 @@ func isAbbreviation(s string, periodPos int) bool {
 		if start >= 0 && start < len(s) {
 			// Extract the text before the period
 			beforePeriod := s[start:periodPos]
-			// Case-insensitive comparison
+			// Case-insensitive comparison, whole word only: "Vol." but not "Protocol."
 			if strings.EqualFold(beforePeriod, abbr) {
-				return true
+				prev, _ := utf8.DecodeLastRuneInString(s[:start])
+				if start == 0 || !unicode.IsLetter(prev) {
+					return true
+				}
 			}
 		}
 	}
@@ -217,12 +273,63 @@ const (
 	TypeOther
 )
 
+// String is the type name written to the References sheet
+func (t ReferenceType) String() string {
+	switch t {
+	case TypeArticle:
+		return "article"
+	case TypeBook:
+		return "book"
+	case TypeChapter:
+		return "chapter"
+	case TypeOnline:
+		return "online"
+	default:
+		return "other"
+	}
+}
+
+// ParsedReference is a reference split into its parts. Meta fields that do not
+// apply to the reference type (or were not found) are empty.
+type ParsedReference struct {
+	Type    ReferenceType
+	Authors string
+	Year    string
+	// Authors split into names; EtAl when the list ends with "et al."
+	AuthorList []RefAuthor
+	EtAl       bool
+	Title      string
+	Meta       string // raw text after the title
+	// TitleTranslated is the bracketed translation of a non-English title
+	TitleTranslated string
+	// Language is "ru" for references marked "[In Russian]" or written in Cyrillic
+	Language string
+	// Confidence (0..1) in the parse and the heuristics behind it, see reference_confidence.go
+	Confidence float64
+	Heuristics []string
+
+	Container  string // journal title (articles) or book title (chapters)
+	Volume     string // "41" from "Vol.41"
+	Issue      string // "3" from "No.3"
+	Pages      string // "123–130" from "P.123–130"
+	City       string // "Moscow" from "Moscow: Mir"
+	Publisher  string // "Mir"
+	TotalPages string // "667" from "667 p."
+	URL        string // first link that is not a DOI resolver
+	AccessDate string // as printed: "12.03.2024", "March 12, 2024"
+	DOI        string // normalized like article DOIs: lowercase, no URL or label
+	PMID       string
+	ISBN       string // digits only, check digit verified
+}
+
 // detectReferenceType analyzes the text after year to determine reference type
 func detectReferenceType(afterYear string) ReferenceType {
 	lower := strings.ToLower(afterYear)
 
 	// Online resources (check first as they're distinct)
-	if strings.Contains(lower, "available from:") ||
+	if strings.Contains(lower, "режим доступа") ||
+		strings.Contains(lower, "дата обращения") ||
+		strings.Contains(lower, "available from:") ||
 		strings.Contains(lower, "available at:") ||
 		strings.Contains(lower, "available online:") ||
 		strings.Contains(lower, "available on:") ||
@@ -241,6 +348,8 @@ func detectReferenceType(afterYear string) ReferenceType {
 		strings.Contains(lower, "(ed):") ||
 		strings.Contains(lower, "(hrsg)") ||
 		strings.Contains(lower, "(hrsg.)") ||
+		strings.Contains(lower, "(ред.)") ||
+		strings.Contains(lower, "(отв. ред.)") ||
 		strings.Contains(lower, "(eds):") {
 		return TypeChapter
 	}
@@ -261,6 +370,10 @@ func detectReferenceType(afterYear string) ReferenceType {
 	return TypeOther
 }
 
+// Go's \b is ASCII-only, so the city is anchored on a non-letter
+// The city is either whole words or one abbreviation with a dot ("М.", "СПб.")
+var cityPubRe = regexp.MustCompile(`(?:^|[^\p{L}])(\p{Lu}[\p{L}\-]*(?:\s+\p{Lu}[\p{L}\-]*)*(?:,\s+\p{Lu}[\p{L}]*)?|\p{Lu}\p{L}{0,3}\.)\s*:\s*[\p{Lu}«"]`)
+
 // findPublisherPattern looks for "City: Publisher" or "City, State: Publisher"
 // Returns the position where this pattern starts, or -1 if not found
 func findPublisherPattern(s string) int {
@@ -268,9 +381,9 @@ func findPublisherPattern(s string) int {
 	// e.g., "Moscow: Nauka", "New York: Academic Press", "Cambridge: MIT Press"
 	// Publisher name can be all caps (MIT) or capitalized (Press)
 	// Updated to handle multi-word city names (e.g., "New York", "San Francisco")
-	cityPubRe := regexp.MustCompile(`\b([A-Z][a-zA-Z]+(?:\s+[A-Z][a-zA-Z]+)*(?:,\s+[A-Z][a-zA-Z]+)?)\s*:\s*[A-Z]`)
-
-	if match := cityPubRe.FindStringIndex(s); match != nil {
+	// Unicode-aware for Russian cities, also abbreviated: "М.: Наука", "СПб.: Изд-во «Наука»"
+	if m := cityPubRe.FindStringSubmatchIndex(s); m != nil {
+		match := m[2:]
 		beforeMatch := s[:match[0]]
 
 		// Check if there's a "//" before this position (already in meta section)
@@ -293,11 +406,11 @@ func findPublisherPattern(s string) int {
 }
 
 // splitTitleMeta takes text after the year (already cleaned of leading ". ")
-// and returns title and raw meta (no deep parsing)
-func splitTitleMeta(after string) (string, string) {
+// and returns title and raw meta (no deep parsing), plus the heuristics that decided the split
+func splitTitleMeta(after string) (string, string, []string) {
 	s := strings.TrimSpace(after)
 	if s == "" {
-		return "", ""
+		return "", "", []string{splitEmpty}
 	}
 
 	// Detect reference type for smarter parsing
@@ -324,7 +437,7 @@ func splitTitleMeta(after string) (string, string) {
 			// Remove any leading punctuation and whitespace from meta
 			meta = strings.TrimPrefix(meta, ". ")
 
-			return title, meta
+			return title, meta, []string{splitBracketedTitle}
 		}
 	}
 
@@ -338,7 +451,7 @@ func splitTitleMeta(after string) (string, string) {
 			meta := s[match[1]:]
 			// Remove any leading punctuation and whitespace from meta
 			meta = strings.TrimLeft(meta, " \t./")
-			return title, meta
+			return title, meta, []string{splitEditorPattern}
 		}
 	}
 
@@ -361,7 +474,7 @@ func splitTitleMeta(after string) (string, string) {
 			if strings.HasPrefix(meta, "//") {
 				meta = strings.TrimPrefix(meta[2:], ". ")
 			}
-			return title, meta
+			return title, meta, []string{splitDoubleSlash}
 		}
 	}
 
@@ -370,7 +483,7 @@ func splitTitleMeta(after string) (string, string) {
 	if pubIdx := findPublisherPattern(s); pubIdx != -1 {
 		title := strings.TrimSpace(s[:pubIdx])
 		meta := strings.TrimSpace(s[pubIdx:])
-		return title, meta
+		return title, meta, []string{splitPublisherPattern}
 	}
 
 	// 5) Search for publication markers with improved logic
@@ -378,10 +491,10 @@ func splitTitleMeta(after string) (string, string) {
 
 	if firstPeriodIdx == -1 {
 		// No period found - search for markers in the entire text, but not too early
-		if idx := findFirstOfMarkersFrom(s, publicationMarkers, minMarkerPosition); idx != -1 {
+		if idx := findFirstOfMarkersFrom(s, referenceMarkers(s), minMarkerPosition); idx != -1 {
 			title := strings.TrimSpace(s[:idx])
 			meta := strings.TrimSpace(s[idx:])
-			return title, meta
+			return title, meta, []string{splitPublicationMarker}
 		}
 	} else {
 		// Period found - only search for markers starting from the second sentence
@@ -394,25 +507,27 @@ func splitTitleMeta(after string) (string, string) {
 		if searchStart < len(s) {
 			// Search for markers only in the text after the first sentence
 			textAfterFirstSentence := s[searchStart:]
-			if idx := findFirstOfMarkers(textAfterFirstSentence, publicationMarkers); idx != -1 {
+			if idx := findFirstOfMarkers(textAfterFirstSentence, referenceMarkers(s)); idx != -1 {
 				// Adjust index to be relative to the original string
 				actualIdx := searchStart + idx
 				title := strings.TrimSpace(s[:actualIdx])
 				meta := strings.TrimSpace(s[actualIdx:])
-				return title, meta
+				return title, meta, []string{splitPublicationMarker}
 			}
 		}
 	}
 
 	// 6) Fallback: look for a period followed by CAPITAL letter or '[' or '('
 	// BUT: skip periods that are part of abbreviations and respect minimum title length
+	// Lengths are counted in runes: Cyrillic letters take two bytes
+	var trail []string
 	found := -1
-	maxScan := len(s)
-	if maxScan > maxTitleScanLength {
-		maxScan = maxTitleScanLength
-	}
-
-	for i := 0; i < maxScan; i++ {
+	runes := 0
+	for i := 0; i < len(s) && runes < maxTitleScanLength; i++ {
+		if !utf8.RuneStart(s[i]) {
+			continue
+		}
+		runes++
 		if s[i] == '.' {
 			// Skip if this is an abbreviation
 			if isAbbreviation(s, i) {
@@ -420,7 +535,7 @@ func splitTitleMeta(after string) (string, string) {
 			}
 
 			// Skip if title would be too short
-			if i < minTitleLength {
+			if runes-1 < minTitleLength {
 				continue
 			}
 
@@ -429,8 +544,8 @@ func splitTitleMeta(after string) (string, string) {
 				j++
 			}
 			if j < len(s) {
-				ch := s[j]
-				if (ch >= 'A' && ch <= 'Z') || ch == '[' || ch == '(' {
+				ch, _ := utf8.DecodeRuneInString(s[j:])
+				if unicode.IsUpper(ch) || ch == '[' || ch == '(' {
 					found = i
 					break
 				}
@@ -444,20 +559,22 @@ func splitTitleMeta(after string) (string, string) {
 
 		// Validate the split makes sense
 		if validateSplit(title, meta) {
-			return title, meta
+			return title, meta, []string{splitPeriodFallback}
 		}
+		trail = append(trail, splitValidateRejected)
 	}
 
 	// last resort: everything is title
-	return s, ""
+	return s, "", append(trail, splitNone)
 }
 
 // validateSplit checks if the title/meta split is reasonable
 func validateSplit(title, meta string) bool {
 	// Title shouldn't be too short relative to total length
-	totalLen := len(title) + len(meta)
+	titleLen := utf8.RuneCountInString(title)
+	totalLen := titleLen + utf8.RuneCountInString(meta)
 	if totalLen > 0 {
-		titleRatio := float64(len(title)) / float64(totalLen)
+		titleRatio := float64(titleLen) / float64(totalLen)
 		if titleRatio < titleMetaLengthRatio {
 			return false
 		}
@@ -465,8 +582,8 @@ func validateSplit(title, meta string) bool {
 
 	// Meta shouldn't start with lowercase (indicates split mid-sentence)
 	if len(meta) > 0 {
-		firstChar := rune(meta[0])
-		if firstChar >= 'a' && firstChar <= 'z' {
+		firstChar, _ := utf8.DecodeRuneInString(meta)
+		if unicode.IsLower(firstChar) {
 			return false
 		}
 	}
@@ -474,16 +591,22 @@ func validateSplit(title, meta string) bool {
 	return true
 }
 
-// parseReference: main orchestration; returns authors, year, title, meta(raw)
-func parseReference(ref string) (string, string, string, string) {
+// parseReference: main orchestration; splits authors, year, title and meta,
+// then decomposes meta according to the reference type
+func parseReference(ref string) ParsedReference {
 	ref = strings.TrimSpace(ref)
 	// normalize whitespace
 	ref = regexp.MustCompile(`\s+`).ReplaceAllString(ref, " ")
 
+	ref, lang := stripLanguageNote(ref)
+
 	startIdx, endIdx, year := pickYearIndex(ref)
 	if startIdx == -1 {
 		// no year found — keep whole ref as title
-		return "Not mentioned", "Not mentioned", ref, ""
+		pr := ParsedReference{Type: detectReferenceType(ref), Authors: "Not mentioned", Year: "Not mentioned", Title: ref, Language: lang}
+		parseReferenceMeta(&pr)
+		scoreReference(&pr, []string{splitNoYear})
+		return pr
 	}
 
 	beforeYear := strings.TrimSpace(ref[:startIdx])
@@ -505,8 +628,24 @@ func parseReference(ref string) (string, string, string, string) {
 		authors = strings.TrimSpace(beforeYear)
 	}
 
+	// "A manual of acarology. 2009. 3rd edition. ...": the title comes before the year
+	if authors == "Not mentioned" && isTitleBeforeYear(beforeYear) {
+		pr := ParsedReference{
+			Type:     detectReferenceType(beforeYear + " " + afterYear),
+			Authors:  authors,
+			Year:     year,
+			Title:    beforeYear,
+			Meta:     strings.TrimSpace(afterYear),
+			Language: lang,
+		}
+		pr.Title, pr.TitleTranslated = splitTranslatedTitle(pr.Title, lang)
+		parseReferenceMeta(&pr)
+		scoreReference(&pr, []string{splitTitleFirst})
+		return pr
+	}
+
 	// now split title/meta from the remainder
-	title, meta := splitTitleMeta(afterYear)
+	title, meta, trail := splitTitleMeta(afterYear)
 
 	// if authors were an institution, move the beforeYear phrase into meta (unless meta already contains it)
 	if authors == "Not mentioned" && beforeYear != "" {
@@ -526,5 +665,48 @@ func parseReference(ref string) (string, string, string, string) {
 		meta = strings.TrimSpace(parts[1])
 	}
 
-	return authors, year, title, meta
+	pr := ParsedReference{
+		Type:    detectReferenceType(afterYear),
+		Authors: authors,
+		Year:    year,
+		Title:   title,
+		Meta:    meta,
+
+		Language: lang,
+	}
+	pr.Title, pr.TitleTranslated = splitTranslatedTitle(title, lang)
+	pr.AuthorList, pr.EtAl = splitReferenceAuthors(authors)
+	parseReferenceMeta(&pr)
+	scoreReference(&pr, trail)
+	return pr
+}
+
+// stripLanguageNote removes "[In Russian]" notes; the language is "ru" for them
+// and for references in Cyrillic, "" otherwise
+func stripLanguageNote(ref string) (string, string) {
+	if languageNoteRe.MatchString(ref) {
+		ref = strings.Join(strings.Fields(languageNoteRe.ReplaceAllString(ref, " ")), " ")
+		return strings.ReplaceAll(ref, " .", "."), "ru"
+	}
+	if cyrillicRegex.MatchString(ref) {
+		return ref, "ru"
+	}
+	return ref, ""
+}
+
+// splitTranslatedTitle returns the title and its bracketed translation.
+// "[Ecology]." is only a translation and stays the title;
+// "Ekologiya [Ecology]" in a Russian reference is split in two.
+func splitTranslatedTitle(title, lang string) (string, string) {
+	t := strings.TrimSpace(title)
+	if strings.HasPrefix(t, "[") && strings.Index(t, "]") >= len(strings.TrimSuffix(t, "."))-1 {
+		return title, strings.Trim(t, "[]. ")
+	}
+	if lang != "ru" {
+		return title, "" // brackets in English titles are usually taxa: "New species of Carabus [Coleoptera]"
+	}
+	if m := translatedTitleRe.FindStringSubmatch(t); m != nil {
+		return strings.TrimSpace(m[1]), strings.TrimSpace(m[2])
+	}
+	return title, ""
 }
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// RefAuthor is one cited author: "van der Berg" + "J.K."
+type RefAuthor struct {
+	Surname  string
+	Initials string
+}
+
+var (
+	// "et al.", "et al", "и др."
+	refEtAlRegex = regexp.MustCompile(`(?i)[,;]?\s*(?:\bet\s+al\.?|(?:^|[^\p{L}])и\s+др\.?)\s*$`)
+	// "&", "and", Cyrillic "и" between the last two authors
+	refAuthorAndRegex = regexp.MustCompile(`\s+(?:&|and|и)\s+`)
+	// one initial or a run of them: "J.", "S.A.", "Yu.", "J.-P.", "Ch.M."
+	refInitialsRegex = regexp.MustCompile(`^(?:\p{Lu}\p{Ll}{0,2}\.-?)+$`)
+)
+
+// splitReferenceAuthors splits "Abramov S.A., van der Berg J. & Smith J. et al." into
+// individual authors. Handles initials before or after the surname and the
+// "Smith, J., Brown, K." style where a comma also separates surname from initials.
+// etAl reports a trailing "et al."/"и др.".
+func splitReferenceAuthors(raw string) (authors []RefAuthor, etAl bool) {
+	s := strings.TrimSpace(raw)
+	if s == "" || s == "Not mentioned" {
+		return nil, false
+	}
+	if loc := refEtAlRegex.FindStringIndex(s); loc != nil {
+		etAl = true
+		s = s[:loc[0]]
+	}
+	s = refAuthorAndRegex.ReplaceAllString(s, ", ")
+
+	var names []string
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ';' }) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		// "Smith, J." - initials alone belong to the previous surname
+		if len(names) > 0 && isInitials(part) && !hasInitials(names[len(names)-1]) {
+			names[len(names)-1] += " " + part
+			continue
+		}
+		names = append(names, part)
+	}
+
+	for _, name := range names {
+		if a := splitRefAuthorName(name); a.Surname != "" {
+			authors = append(authors, a)
+		}
+	}
+	return authors, etAl
+}
+
+// splitRefAuthorName splits "Abramov S.A.", "J.K. Smith" or "van der Berg J." into surname and initials
+func splitRefAuthorName(name string) RefAuthor {
+	var surname, initials []string
+	for _, w := range strings.Fields(name) {
+		if isInitials(w) {
+			initials = append(initials, w)
+		} else {
+			surname = append(surname, w)
+		}
+	}
+	return RefAuthor{
+		Surname:  strings.TrimSuffix(strings.Join(surname, " "), "."),
+		Initials: strings.Join(initials, ""),
+	}
+}
+
+func isInitials(s string) bool {
+	return refInitialsRegex.MatchString(strings.ReplaceAll(s, " ", ""))
+}
+
+func hasInitials(name string) bool {
+	for _, w := range strings.Fields(name) {
+		if isInitials(w) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// "DOI: 10.1134/S1062359014030029", "doi:10...", "https://doi.org/10..."
+	refDOILabelRegex = regexp.MustCompile(`(?i)\b(?:doi\s*:?\s*|https?://(?:dx\.)?doi\.org/)?10\.\d{4,9}/[^\s"<>]+`)
+	// DOI resolver links are written as DOI, not as URL
+	refDOIURLRegex = regexp.MustCompile(`(?i)^https?://(?:dx\.)?doi\.org/`)
+	refPMIDRegex   = regexp.MustCompile(`(?i)\bPMID\s*:?\s*(\d{1,8})\b`)
+	// "ISBN 978-5-02-038367-1", "ISBN: 5-02-025712-4", "ISBN-13 9780123456786"
+	refISBNRegex = regexp.MustCompile(`(?i)\bISBN(?:-1[03])?\s*:?\s*([\dX](?:[\s‐–-]?[\dX]){9,12})\b`)
+)
+
+// extractReferenceIDs fills the DOI, URL, PMID and ISBN of a reference from text
+func extractReferenceIDs(pr *ParsedReference, text string) {
+	pr.DOI = normalizeDOI(text)
+
+	for _, m := range refURLRegex.FindAllString(text, -1) {
+		if !refDOIURLRegex.MatchString(m) {
+			pr.URL = strings.TrimRight(m, ".,;)]")
+			break
+		}
+	}
+
+	if m := refPMIDRegex.FindStringSubmatch(text); m != nil {
+		pr.PMID = m[1]
+	}
+
+	if m := refISBNRegex.FindStringSubmatch(text); m != nil {
+		if isbn := normalizeISBN(m[1]); validISBN(isbn) {
+			pr.ISBN = isbn
+		}
+	}
+}
+
+// stripReferenceIDs removes URLs, DOIs, PMIDs and ISBNs from reference meta
+func stripReferenceIDs(meta string) string {
+	for _, re := range []*regexp.Regexp{refURLRegex, refDOILabelRegex, refPMIDRegex, refISBNRegex} {
+		meta = re.ReplaceAllString(meta, " ")
+	}
+	return meta
+}
+
+// normalizeISBN keeps only digits and the X check character
+func normalizeISBN(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(s) {
+		if (r >= '0' && r <= '9') || r == 'X' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// validISBN checks the check digit of a normalized ISBN-10 or ISBN-13
+func validISBN(isbn string) bool {
+	switch len(isbn) {
+	case 10:
+		total := 0
+		for i, r := range isbn {
+			d := int(r - '0')
+			if r == 'X' {
+				if i != 9 {
+					return false
+				}
+				d = 10
+			}
+			total += (10 - i) * d
+		}
+		return total%11 == 0
+	case 13:
+		total := 0
+		for i, r := range isbn {
+			if r == 'X' {
+				return false
+			}
+			w := 1
+			if i%2 == 1 {
+				w = 3
+			}
+			total += w * int(r-'0')
+		}
+		return total%10 == 0
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package main
+
+import "testing"
+
+func TestValidISBN(t *testing.T) {
+	tests := []struct {
+		isbn string
+		want bool
+	}{
+		{"0306406152", true},
+		{"080442957X", true}, // check digit 10
+		{"097522980X", true},
+		{"9780306406157", true},
+		{"9785020383678", true},
+		{"0306406153", false},
+		{"0804429579", false},
+		{"X306406152", false}, // X only as the ISBN-10 check digit
+		{"978030640615X", false},
+		{"9780306406158", false},
+		{"030640615", false},
+		{"03064061521", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := validISBN(tt.isbn); got != tt.want {
+			t.Errorf("validISBN(%q) = %v, want %v", tt.isbn, got, tt.want)
+		}
+	}
+}
+
+func TestExtractReferenceISBN(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Kryzhanovskij O.L. 1983. The ground beetles. Leningrad: Nauka. 341 p. ISBN 978-5-02-038367-8", "9785020383678"},
+		{"Smith J. 2001. Beetles. London. ISBN: 0-8044-2957-X.", "080442957X"},
+		{"Smith J. 2001. Beetles. London. ISBN-10 0 8044 2957 x", "080442957X"},
+		{"Smith J. 2001. Beetles. London. ISBN 0-8044-2957-9", ""},
+	}
+	for _, tt := range tests {
+		var pr ParsedReference
+		extractReferenceIDs(&pr, tt.text)
+		if pr.ISBN != tt.want {
+			t.Errorf("ISBN of %q = %q, want %q", tt.text, pr.ISBN, tt.want)
+		}
+	}
+}
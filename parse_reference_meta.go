@@ -0,0 +1,128 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+/*
+REFERENCE META DECOMPOSITION
+
+parseReference leaves everything after the title in Meta. parseReferenceMeta
+splits it further, depending on the reference type:
+
+	Article:  Biology Bulletin. Vol.41. No.3. P.123–130.
+	          -> Container, Volume, Issue, Pages
+	Book:     Moscow: Mir. 667 p.
+	          -> City, Publisher, TotalPages
+	Chapter:  Handbook of Environmental Chemistry. Berlin: Springer. P.1–20.
+	          -> Container, City, Publisher, Pages
+	Online:   Available from: https://www.gbif.org/ (accessed 12 March 2024).
+	          -> URL, AccessDate
+
+Volume, issue, pages, URL and access date are looked up for every type, since
+books have volumes and articles have URLs too. City and publisher are not
+searched in articles: "Journal of Natural History: Series B" is not a publisher.
+*/
+
+var (
+	// "Vol.41", "Vol. 41(2)", "Т.12", "Bd.5"
+	refVolumeRegex = regexp.MustCompile(`(?:\b(?:Vol|Bd|Tome)|(?:^|[^\p{L}])Т)\.\s*(\d+[A-Za-z]?)`)
+	// "No.3", "Nr.3", "№ 3", "Вып.3", "Iss.3", "Issue 3", "No.1-2"
+	refIssueRegex = regexp.MustCompile(`(?:\b(?:No|Nr|Iss|Issue)\.?|№|(?:^|[^\p{L}])Вып\.)\s*(\d+(?:\s*[-–—/]\s*\d+)?)`)
+	// "41(3)" right after the container when there are no labels
+	refVolIssueRegex = regexp.MustCompile(`(?:^|[.,]\s*)(\d+)\s*\((\d+(?:[-–—/]\d+)?)\)`)
+	// "P.123–130", "pp. 12-15", "С.45–50", "S.12", "Art.e1234"
+	refPagesRegex = regexp.MustCompile(`(?:\b(?:P{1,2}|pp?|S|Art)|(?:^|[^\p{L}])С)\.\s*([\dA-Za-z]*\d+(?:\s*[-–—]\s*\d+)?)`)
+	// "667 p.", "xii+320 pp.", "250 с."
+	refTotalPagesRegex = regexp.MustCompile(`(?:^|[\s.,])((?:[ivxlc]+\s*\+\s*)?\d+)\s*(?:pp?|pages|с|S)\.?(?:[\s,;]|$)`)
+	// "Moscow: Mir", "New York: Academic Press", "St. Petersburg: Nauka", "М.: Наука", "СПб.: Изд-во «Наука»"
+	refCityPubRegex    = regexp.MustCompile(`(?:^|[.,;–—]\s*)((?:St\.\s*)?\p{Lu}[\p{L}'\-]*(?:\s+\p{Lu}[\p{L}'\-]*)*(?:,\s*\p{Lu}[\p{L}]*)?|\p{Lu}\p{L}{0,3}\.)\s*:\s*([\p{Lu}«"].*?)(?:[.,]\s*(?:\d|[PС]\.|pp?\.|[ivxlc]+\s*\+)|\.?\s*$)`)
+	refURLRegex        = regexp.MustCompile(`https?://\S+`)
+	refAccessDateRegex = regexp.MustCompile(`(?i)(?:last\s+)?(?:accessed|visited|retrieved|дата обращения)(?:\s+on)?\s*[:\s]\s*([^()\[\]]+)`)
+	// container ends at the first volume/issue/pages label or a bare volume number
+	refContainerEndRegex = regexp.MustCompile(`(?:[.,]\s*(?:Vol|Bd|No|Nr|Iss|P{1,2}|pp?)\.|[.,]?\s*(?:№|Т\.|Вып\.|С\.)|[.,]\s*\d)`)
+)
+
+// russianCityAbbreviations are the cities Russian references abbreviate
+var russianCityAbbreviations = map[string]string{
+	"М.":   "Москва",
+	"Л.":   "Ленинград",
+	"СПб.": "Санкт-Петербург",
+	"К.":   "Киев",
+	"Мн.":  "Минск",
+}
+
+// parseReferenceMeta fills the decomposed meta fields of pr from pr.Meta
+func parseReferenceMeta(pr *ParsedReference) {
+	meta := strings.TrimSpace(pr.Meta)
+
+	// online references often have no title/meta split, so identifiers are looked up in the title too
+	extractReferenceIDs(pr, meta+" "+pr.Title)
+	if m := refAccessDateRegex.FindStringSubmatch(meta); m != nil && pr.AccessDate == "" {
+		pr.AccessDate = strings.TrimRight(strings.TrimSpace(m[1]), ".,;")
+	}
+	if meta == "" {
+		return
+	}
+
+	// URLs and identifiers contain dots and digits that look like labels
+	rest := stripReferenceIDs(meta)
+
+	// Fields already set by a citation style profile are kept
+	if m := refVolumeRegex.FindStringSubmatch(rest); m != nil && pr.Volume == "" {
+		pr.Volume = m[1]
+	}
+	if m := refIssueRegex.FindStringSubmatch(rest); m != nil && pr.Issue == "" {
+		pr.Issue = normalizeRange(m[1])
+	}
+	if pr.Volume == "" {
+		if m := refVolIssueRegex.FindStringSubmatch(rest); m != nil {
+			pr.Volume = m[1]
+			if pr.Issue == "" {
+				pr.Issue = normalizeRange(m[2])
+			}
+		}
+	}
+	if m := refPagesRegex.FindStringSubmatch(rest); m != nil && pr.Pages == "" {
+		pr.Pages = normalizeRange(m[1])
+	}
+	if m := refTotalPagesRegex.FindStringSubmatch(rest); m != nil && pr.TotalPages == "" {
+		pr.TotalPages = strings.Join(strings.Fields(m[1]), "")
+	}
+
+	if pr.Type != TypeArticle && pr.Publisher == "" {
+		if m := refCityPubRegex.FindStringSubmatchIndex(rest); m != nil {
+			pr.City = strings.TrimSpace(rest[m[2]:m[3]])
+			if full, ok := russianCityAbbreviations[pr.City]; ok {
+				pr.City = full
+			}
+			pr.Publisher = strings.TrimSpace(rest[m[4]:m[5]])
+		}
+	}
+
+	// citation styles that mark the container explicitly set it before
+	if pr.Container == "" && (pr.Type == TypeArticle || pr.Type == TypeChapter) {
+		pr.Container = referenceContainer(rest, pr.City)
+	}
+}
+
+// referenceContainer is the meta text before the first label, the city or a bare number
+func referenceContainer(meta, city string) string {
+	end := len(meta)
+	if loc := refContainerEndRegex.FindStringIndex(meta); loc != nil {
+		end = loc[0]
+	}
+	if city != "" {
+		if idx := strings.Index(meta, city+":"); idx != -1 && idx < end {
+			end = idx
+		}
+	}
+	return strings.Trim(strings.TrimSpace(meta[:end]), ".,;: ")
+}
+
+// normalizeRange removes spaces around a range dash and uses an en dash: "123 - 130" -> "123–130"
+func normalizeRange(s string) string {
+	s = strings.Join(strings.Fields(s), "")
+	return strings.NewReplacer("-", "–", "—", "–").Replace(s)
+}
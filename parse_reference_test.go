@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseReferenceCorpus(t *testing.T) {
+	cases, err := loadReferenceCorpus(referenceCorpusPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("%02d_%s", i+1, c.Type), func(t *testing.T) {
+			pr, mismatched, err := c.check()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.KnownFailure != "" {
+				if len(mismatched) == 0 {
+					t.Errorf("known failure now parses correctly, remove known_failure: %s", c.Ref)
+				} else {
+					t.Skipf("known failure: %s", c.KnownFailure)
+				}
+				return
+			}
+			if len(mismatched) > 0 {
+				t.Errorf("%s\nmismatched: %v\ngot:  type=%s authors=%q year=%q title=%q meta=%q\nwant: type=%s authors=%q year=%q title=%q meta=%q",
+					c.Ref, mismatched,
+					pr.Type, pr.Authors, pr.Year, pr.Title, pr.Meta,
+					c.Type, c.Authors, c.Year, c.Title, c.Meta)
+			}
+		})
+	}
+}
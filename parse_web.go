@@ -2,7 +2,6 @@ package main
 
 import (
 	"fmt"
-	"log"
 	"net/http"
 	"regexp"
 	"strings"
@@ -18,16 +17,18 @@ type JournalInfo struct {
 	Links   []string
 }
 
+// kmkjournalsBaseURL is the site with the journals' volume indexes; tests point it at a local server
+var kmkjournalsBaseURL = "https://kmkjournals.com"
+
 // GetJournalPage extracts journal information from DOI and fetches article links
 // DOI format examples:
 // - euroasentj.24.03.02 (EEJ journal, volume 24, number 3)
 // - rusentj.34.3.01 (REJ journal, vol 34, number 3)
 // - invertzool.22.3.01 (IZ journal, volume 22 number 3)
 // - arthsel.34.3.01 (AS journal, volume 34, number 3)
-func GetJournalPage(doi string) JournalInfo {
+func GetJournalPage(doi string) (JournalInfo, error) {
 	if doi == "" {
-		log.Fatalf("DOI is empty")
-		return JournalInfo{}
+		return JournalInfo{}, fmt.Errorf("DOI is empty")
 	}
 
 	// Parse DOI to extract journal, volume, and number
@@ -36,51 +37,35 @@ func GetJournalPage(doi string) JournalInfo {
 	doiRegex := regexp.MustCompile(`(?:[\d.]+/)?([a-z]+)\.(\d+)\.(\d+)\.(\d+)$`)
 	matches := doiRegex.FindStringSubmatch(doi)
 	if len(matches) != 5 {
-		log.Fatalf("Invalid DOI format: %s. Expected format: [prefix/]journal.volume.number.article (e.g., 10.15298/euroasentj.24.01.01)", doi)
-		return JournalInfo{}
+		return JournalInfo{}, fmt.Errorf("invalid DOI format: %s. Expected format: [prefix/]journal.volume.number.article (e.g., 10.15298/euroasentj.24.01.01)", doi)
 	}
 
 	journalCode := matches[1]
 	journalVol := strings.TrimPrefix(matches[2], "0")
 	journalNum := strings.TrimPrefix(matches[3], "0")
 
-	// Map DOI journal codes to full journal prefixes
-	journalCodeMap := map[string]string{
-		"euroasentj":  "EEJ",
-		"rusentj":     "REJ",
-		"invertzool":  "IZ",
-		"arthsel":     "AS",
-	}
-
-	journalPrefix, ok := journalCodeMap[journalCode]
+	// Map DOI journal codes to journal catalog pages
+	journal, ok := journalByDOICode(journalCode)
 	if !ok {
-		log.Fatalf("Unknown journal code in DOI: %s", journalCode)
-		return JournalInfo{}
+		return JournalInfo{}, fmt.Errorf("unknown journal code in DOI: %s", journalCode)
 	}
 
-	journalKeyCatalogMap := map[string]string{
-		"IZ":  "Inv_Zool",
-		"AS":  "AS",
-		"REJ": "REJ",
-		"EEJ": "EEJ",
-	}
-
-	journalURL := fmt.Sprintf("https://kmkjournals.com/journals/%s/%s_Index_Volumes",
-		journalKeyCatalogMap[journalPrefix], journalPrefix)
+	journalURL := fmt.Sprintf("%s/journals/%s/%s_Index_Volumes",
+		kmkjournalsBaseURL, journal.CatalogKey, journal.Code)
 
 	// Fetch page
 	res, err := http.Get(journalURL)
 	if err != nil {
-		log.Fatalf("Failed to fetch page: %v", err)
+		return JournalInfo{}, fmt.Errorf("failed to fetch %s: %w", journalURL, err)
 	}
 	defer res.Body.Close()
 	if res.StatusCode != 200 {
-		log.Fatalf("Status code error: %d %s", res.StatusCode, res.Status)
+		return JournalInfo{}, fmt.Errorf("failed to fetch %s: status %s", journalURL, res.Status)
 	}
 
 	doc, err := goquery.NewDocumentFromReader(res.Body)
 	if err != nil {
-		log.Fatalf("Failed to parse HTML: %v", err)
+		return JournalInfo{}, fmt.Errorf("failed to parse %s: %w", journalURL, err)
 	}
 
 	// 1. Find <h1> with Volume
@@ -110,12 +95,10 @@ func GetJournalPage(doi string) JournalInfo {
 	})
 
 	if numberNode == nil {
-		log.Fatalf("Could not find Volume %s Number %s", journalVol, journalNum)
-		return JournalInfo{}
+		return JournalInfo{}, fmt.Errorf("could not find Volume %s Number %s on %s", journalVol, journalNum, journalURL)
 	}
 	links := []string{}
 	// 3. Collect articles until next Number/Volume
-	baseURL := "https://kmkjournals.com"
 	for s := numberNode.Next(); s.Length() > 0; s = s.Next() {
 		if goquery.NodeName(s) == "h1" && strings.Contains(s.Text(), "Volume") {
 			break
@@ -132,7 +115,7 @@ func GetJournalPage(doi string) JournalInfo {
 			if linkSel.Length() > 0 {
 				href, _ := linkSel.Attr("href")
 				if href != "" && !strings.Contains(href, ".pdf") {
-					links = append(links, baseURL+href)
+					links = append(links, kmkjournalsBaseURL+href)
 				}
 			}
 		}
@@ -143,5 +126,5 @@ func GetJournalPage(doi string) JournalInfo {
 		Issue:   journalNum,
 		Pubdate: pubdate,
 		Links:   links,
-	}
+	}, nil
 }
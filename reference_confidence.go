@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+REFERENCE CONFIDENCE
+
+Every parsed reference gets a confidence score (0..1) and the trail of
+heuristics that produced it, so editors only review the doubtful rows.
+The score starts from how reliable the title/meta split was and is lowered
+for each missing or suspicious part. Rows below lowConfidenceThreshold are
+highlighted on the References sheet.
+*/
+
+// Title/meta split heuristics, in the order splitTitleMeta tries them
+const (
+	splitEmpty             = "empty after year"
+	splitBracketedTitle    = "bracketed title"
+	splitEditorPattern     = "editor pattern"
+	splitDoubleSlash       = "// separator"
+	splitPublisherPattern  = "city:publisher pattern"
+	splitPublicationMarker = "publication marker"
+	splitPeriodFallback    = "period fallback"
+	splitValidateRejected  = "validateSplit rejected period split"
+	splitNone              = "no split, everything is title"
+	splitNoYear            = "no year, everything is title"
+	splitTitleFirst        = "title before the year"
+)
+
+const lowConfidenceThreshold = 0.6
+
+// splitConfidence is how much each deciding heuristic is trusted
+var splitConfidence = map[string]float64{
+	splitEmpty:             0.1,
+	splitBracketedTitle:    0.9,
+	splitEditorPattern:     0.9,
+	splitDoubleSlash:       0.95,
+	splitPublisherPattern:  0.8,
+	splitPublicationMarker: 0.65,
+	splitPeriodFallback:    0.55,
+	splitNone:              0.3,
+	splitNoYear:            0.1,
+	splitTitleFirst:        0.65,
+	splitAPA:               0.9,
+	splitGOST:              0.9,
+}
+
+// scoreReference sets pr.Confidence and pr.Heuristics from the split trail and the parsed fields
+func scoreReference(pr *ParsedReference, trail []string) {
+	score := 1.0
+	if len(trail) > 0 {
+		score = splitConfidence[trail[len(trail)-1]]
+	}
+	heuristics := append([]string{}, trail...)
+
+	penalize := func(factor float64, reason string) {
+		score *= factor
+		heuristics = append(heuristics, reason)
+	}
+
+	if pr.Authors == "Not mentioned" && pr.Year != "Not mentioned" {
+		penalize(0.85, "no author block")
+	}
+	if pr.Type == TypeArticle && pr.Volume == "" && pr.Pages == "" {
+		penalize(0.8, "article without volume or pages")
+	}
+	if pr.Type == TypeArticle && pr.Container == "" {
+		penalize(0.85, "article without journal title")
+	}
+	if words := len(strings.Fields(pr.Title)); words > 0 && words < 2 && pr.TitleTranslated == "" {
+		penalize(0.8, "one-word title")
+	}
+	if pr.Meta != "" && len(pr.Meta) > 3*len(pr.Title) {
+		penalize(0.8, fmt.Sprintf("meta %d times longer than title", len(pr.Meta)/max(len(pr.Title), 1)))
+	}
+
+	pr.Confidence = score
+	pr.Heuristics = heuristics
+}
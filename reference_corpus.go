@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+/*
+REFERENCE CORPUS
+
+testdata/references.json is a golden corpus of references with the split we
+expect from the parser. It is checked by the table test in
+parse_reference_test.go and measured by `refparse eval`, so a change to the
+heuristics shows up as a number instead of a feeling.
+
+Each case gives the reference, optionally the citation style profile (kmk when
+empty), and the expected type, authors, year, title and meta. Cases the parser
+gets wrong today keep the CORRECT expectation and say why in known_failure:
+the test skips them, eval counts them against the accuracy.
+
+Comparison ignores surrounding spaces and a final period, which the parser
+keeps or drops depending on the split.
+*/
+
+const referenceCorpusPath = "testdata/references.json"
+
+// ReferenceCase is one reference of the golden corpus with its expected split
+type ReferenceCase struct {
+	Ref          string `json:"ref"`
+	Style        string `json:"style,omitempty"`
+	Type         string `json:"type"`
+	Authors      string `json:"authors"`
+	Year         string `json:"year"`
+	Title        string `json:"title"`
+	Meta         string `json:"meta"`
+	KnownFailure string `json:"known_failure,omitempty"` // why the parser gets it wrong
+}
+
+// referenceCaseFields are the compared fields, in report order
+var referenceCaseFields = []string{"type", "authors", "year", "title", "meta"}
+
+func loadReferenceCorpus(path string) ([]ReferenceCase, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reference corpus: %w", err)
+	}
+	var cases []ReferenceCase
+	if err := json.Unmarshal(data, &cases); err != nil {
+		return nil, fmt.Errorf("failed to parse reference corpus %s: %w", path, err)
+	}
+	return cases, nil
+}
+
+// check parses the reference with its profile and returns the fields that differ from the expectation
+func (c ReferenceCase) check() (ParsedReference, []string, error) {
+	name := c.Style
+	if name == "" {
+		name = defaultCitationStyle
+	}
+	style, ok := citationStyleByName(name)
+	if !ok {
+		return ParsedReference{}, nil, fmt.Errorf("unknown citation style %q", name)
+	}
+
+	pr := style.Parse(c.Ref)
+	got := map[string]string{
+		"type": pr.Type.String(), "authors": pr.Authors, "year": pr.Year, "title": pr.Title, "meta": pr.Meta,
+	}
+	want := map[string]string{
+		"type": c.Type, "authors": c.Authors, "year": c.Year, "title": c.Title, "meta": c.Meta,
+	}
+
+	var mismatched []string
+	for _, field := range referenceCaseFields {
+		if normalizeCaseField(got[field]) != normalizeCaseField(want[field]) {
+			mismatched = append(mismatched, field)
+		}
+	}
+	return pr, mismatched, nil
+}
+
+func normalizeCaseField(s string) string {
+	return strings.TrimSuffix(strings.TrimSpace(s), ".")
+}
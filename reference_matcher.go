@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+REFERENCE DOI MATCHING
+
+References without a DOI are looked up in a Crossref-compatible REST API
+(GET /works?query.bibliographic=...). Candidates are scored against the parsed
+reference and the best one above refMatchMinScore is written to the References
+sheet as a suggestion with its score. Nothing is inserted into ref.doi: the
+editor decides.
+
+Configuration:
+- REFMATCH_URL: API base, e.g. https://api.crossref.org, or a local mock.
+  Matching is off when it is not set.
+- REFMATCH_MAILTO: contact address sent to Crossref (their "polite pool").
+
+Score (0..1) is a weighted mean over the fields the reference has:
+title 0.5, authors 0.2, year 0.15, container 0.15. A title alone says too
+little ("Introduction", "Carabidae"), so a reference also needs a year or an
+author to be scored; without both every candidate scores 0.
+
+Lookups run one at a time, each limited to refMatchTimeout, and all lookups
+of a conversion to refMatchBudget: the references left after that are not
+looked up, so a slow API cannot hold a conversion for long.
+*/
+
+const (
+	refMatchMinScore    = 0.6
+	refMatchRows        = 5
+	refMatchTimeout     = 10 * time.Second
+	refMatchMaxFailures = 3 // stop querying after this many errors in a row
+)
+
+// refMatchBudget limits the time spent on all lookups of a conversion
+var refMatchBudget = 2 * time.Minute
+
+// ReferenceMatcher suggests a DOI for a reference that has none
+type ReferenceMatcher interface {
+	// Match returns the best candidate, nil if none scores high enough
+	Match(ctx context.Context, ref ParsedReference) (*DOISuggestion, error)
+}
+
+// DOISuggestion is a candidate DOI for editor review
+type DOISuggestion struct {
+	DOI   string
+	Score float64
+	Title string // candidate title, to make the review easier
+}
+
+// CrossrefMatcher queries a Crossref-compatible works endpoint
+type CrossrefMatcher struct {
+	baseURL string
+	mailto  string
+	client  *http.Client
+}
+
+// articleReferences are an article's references parsed in its citation style
+type articleReferences struct {
+	style       CitationStyle
+	texts       []string
+	parsed      []ParsedReference
+	suggestions []*DOISuggestion // per reference, nil without a suggestion
+}
+
+// parseArticleReferences parses the references of every article in the journal's
+// style (CITATION_STYLE, the journal's settings or auto-detection) and, when
+// matcher is not nil, looks up a DOI for each reference without one. Matching
+// stops after refMatchMaxFailures errors in a row or when refMatchBudget is used up.
+func parseArticleReferences(articles []Article, journal JournalEntry, matcher ReferenceMatcher, clog *ConversionLog) ([]articleReferences, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), refMatchBudget)
+	defer cancel()
+	result := make([]articleReferences, len(articles))
+	failures := 0
+	for artI, art := range articles {
+		clog.stage("matching", artI+1, len(articles))
+		style, err := citationStyleFor(journal, art.references)
+		if err != nil {
+			return nil, fmt.Errorf("article %d: %w", artI+1, err)
+		}
+
+		refs := articleReferences{style: style, suggestions: make([]*DOISuggestion, len(art.references))}
+		for i, ref := range art.references {
+			pr := style.Parse(ref)
+			refs.texts = append(refs.texts, ref)
+			refs.parsed = append(refs.parsed, pr)
+			if matcher == nil || pr.DOI != "" || pr.Title == "" {
+				continue
+			}
+
+			suggestion, err := matcher.Match(ctx, pr)
+			if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				clog.Printf("⚠️  DOI matching stopped after %s: references from article %d, reference %d on are not looked up\n", refMatchBudget, artI+1, i+1)
+				matcher = nil
+				continue
+			}
+			if err != nil {
+				failures++
+				clog.printWarning(artI+1, "REFMATCH", fmt.Sprintf("Reference %d: %v", i+1, err))
+				if failures >= refMatchMaxFailures {
+					clog.Printf("⚠️  DOI matching stopped after %d failed requests\n", failures)
+					matcher = nil
+				}
+				continue
+			}
+			failures = 0
+			refs.suggestions[i] = suggestion
+		}
+		result[artI] = refs
+	}
+	return result, nil
+}
+
+// newReferenceMatcher returns the configured matcher, nil when matching is off
+func newReferenceMatcher() ReferenceMatcher {
+	base := os.Getenv("REFMATCH_URL")
+	if base == "" {
+		return nil
+	}
+	return &CrossrefMatcher{
+		baseURL: strings.TrimRight(base, "/"),
+		mailto:  os.Getenv("REFMATCH_MAILTO"),
+		client:  &http.Client{Timeout: refMatchTimeout},
+	}
+}
+
+// crossrefWork is the part of a Crossref work record used for scoring
+type crossrefWork struct {
+	DOI    string   `json:"DOI"`
+	Title  []string `json:"title"`
+	Author []struct {
+		Family string `json:"family"`
+		Name   string `json:"name"` // organizations
+	} `json:"author"`
+	Issued struct {
+		DateParts [][]int `json:"date-parts"`
+	} `json:"issued"`
+	ContainerTitle []string `json:"container-title"`
+}
+
+type crossrefResponse struct {
+	Message struct {
+		Items []crossrefWork `json:"items"`
+	} `json:"message"`
+}
+
+// Match queries the endpoint with the reference's title, authors, year and container
+func (m *CrossrefMatcher) Match(ctx context.Context, ref ParsedReference) (*DOISuggestion, error) {
+	q := url.Values{}
+	q.Set("query.bibliographic", referenceQuery(ref))
+	q.Set("rows", strconv.Itoa(refMatchRows))
+	q.Set("select", "DOI,title,author,issued,container-title")
+	if m.mailto != "" {
+		q.Set("mailto", m.mailto)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.baseURL+"/works?"+q.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", m.baseURL, err)
+	}
+	res, err := m.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", m.baseURL, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", m.baseURL, res.Status)
+	}
+
+	var body crossrefResponse
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode response from %s: %w", m.baseURL, err)
+	}
+
+	var best *DOISuggestion
+	for _, work := range body.Message.Items {
+		score := scoreCandidate(ref, work)
+		if best == nil || score > best.Score {
+			best = &DOISuggestion{DOI: normalizeDOI(work.DOI), Score: score, Title: firstOf(work.Title)}
+		}
+	}
+	if best == nil || best.DOI == "" || best.Score < refMatchMinScore {
+		return nil, nil
+	}
+	return best, nil
+}
+
+// referenceQuery is the free-text query: authors' surnames, year, title and container
+func referenceQuery(ref ParsedReference) string {
+	var parts []string
+	for _, a := range ref.AuthorList {
+		parts = append(parts, a.Surname)
+	}
+	if ref.Year != "Not mentioned" {
+		parts = append(parts, ref.Year)
+	}
+	parts = append(parts, cleanRefTitle(ref.Title), ref.Container)
+	return strings.Join(strings.Fields(strings.Join(parts, " ")), " ")
+}
+
+// scoreCandidate compares a candidate work with the parsed reference;
+// 0 when the reference has no title, or neither a year nor authors
+func scoreCandidate(ref ParsedReference, work crossrefWork) float64 {
+	_, yearErr := strconv.Atoi(ref.Year)
+	if len(matchTokens(cleanRefTitle(ref.Title))) == 0 || (yearErr != nil && len(ref.AuthorList) == 0) {
+		return 0
+	}
+
+	var total, weights float64
+	add := func(weight, score float64) {
+		total += weight * score
+		weights += weight
+	}
+
+	if title := matchTokens(cleanRefTitle(ref.Title)); len(title) > 0 {
+		candidate := matchTokens(firstOf(work.Title))
+		score := tokenDice(title, candidate)
+		// Russian papers are often registered under their English title
+		if translated := matchTokens(ref.TitleTranslated); len(translated) > 0 {
+			score = max(score, tokenDice(translated, candidate))
+		}
+		add(0.5, score)
+	}
+
+	if len(ref.AuthorList) > 0 {
+		var families []string
+		for _, a := range work.Author {
+			families = append(families, strings.ToLower(a.Family+a.Name))
+		}
+		found := 0
+		for _, a := range ref.AuthorList {
+			for _, f := range families {
+				if similarWords(strings.ToLower(a.Surname), f) {
+					found++
+					break
+				}
+			}
+		}
+		add(0.2, float64(found)/float64(len(ref.AuthorList)))
+	}
+
+	if year, err := strconv.Atoi(ref.Year); err == nil {
+		score := 0.0
+		if parts := work.Issued.DateParts; len(parts) > 0 && len(parts[0]) > 0 {
+			switch diff := year - parts[0][0]; {
+			case diff == 0:
+				score = 1
+			case diff == 1 || diff == -1:
+				score = 0.5 // online-first vs. print year
+			}
+		}
+		add(0.15, score)
+	}
+
+	if container := matchTokens(ref.Container); len(container) > 0 {
+		add(0.15, tokenDice(container, matchTokens(firstOf(work.ContainerTitle))))
+	}
+
+	if weights == 0 {
+		return 0
+	}
+	return total / weights
+}
+
+// cleanRefTitle removes the brackets of translated titles: "[Ecology]." -> "Ecology"
+func cleanRefTitle(title string) string {
+	return strings.Trim(strings.TrimSpace(title), "[]. ")
+}
+
+// matchTokens splits a title into lowercase words without stop words
+func matchTokens(s string) []string {
+	var tokens []string
+	for _, w := range strings.Fields(orgPunctRegex.ReplaceAllString(strings.ToLower(s), " ")) {
+		if !orgStopWords[w] {
+			tokens = append(tokens, w)
+		}
+	}
+	return tokens
+}
+
+func firstOf(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
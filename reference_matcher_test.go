@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// crossrefStub serves /works like the Crossref REST API, answering with items for every query
+func crossrefStub(t *testing.T, status int, items ...map[string]any) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path != "/works" || r.URL.Query().Get("query.bibliographic") == "" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		if status != http.StatusOK {
+			http.Error(w, "stub failure", status)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"message": map[string]any{"items": items}})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func crossrefItem(doi, title string, year int, container string, families ...string) map[string]any {
+	var authors []map[string]string
+	for _, f := range families {
+		authors = append(authors, map[string]string{"family": f})
+	}
+	return map[string]any{
+		"DOI":             doi,
+		"title":           []string{title},
+		"author":          authors,
+		"issued":          map[string]any{"date-parts": [][]int{{year}}},
+		"container-title": []string{container},
+	}
+}
+
+const matcherTestRef = "Ivanova A.B., Petrov C.D. 2019. Ground beetles of the Altai Mountains // Euroasian Entomological Journal. Vol.18. No.2. P.101-110."
+
+func TestCrossrefMatcherPicksBestCandidate(t *testing.T) {
+	srv, _ := crossrefStub(t, http.StatusOK,
+		crossrefItem("10.1000/decoy", "Beetles of the world", 2005, "Nature", "Smith"),
+		crossrefItem("10.15298/EuroasEntJ.18.2.05", "Ground beetles of the Altai mountains", 2019, "Euroasian Entomological Journal", "Ivanova", "Petrov"),
+		crossrefItem("10.1000/close", "Ground beetles of the Altai", 2021, "Zootaxa", "Ivanova"),
+	)
+	t.Setenv("REFMATCH_URL", srv.URL+"/")
+	matcher := newReferenceMatcher()
+	if matcher == nil {
+		t.Fatal("REFMATCH_URL is set but matching is off")
+	}
+
+	suggestion, err := matcher.Match(context.Background(), parseReference(matcherTestRef))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if suggestion == nil {
+		t.Fatal("no suggestion for an exact match")
+	}
+	if suggestion.DOI != "10.15298/euroasentj.18.2.05" {
+		t.Errorf("DOI = %q, want the exact match in lowercase", suggestion.DOI)
+	}
+	if suggestion.Score < 0.95 {
+		t.Errorf("score of the exact match = %.2f, want about 1", suggestion.Score)
+	}
+}
+
+func TestCrossrefMatcherThreshold(t *testing.T) {
+	ref := parseReference(matcherTestRef)
+	tests := []struct {
+		name string
+		item map[string]any
+		want bool
+	}{
+		{"unrelated work", crossrefItem("10.1000/x", "Soil nematodes of Brazil", 2001, "Nematology", "Silva"), false},
+		{"same title, other authors and year", crossrefItem("10.1000/y", "Ground beetles of the Altai Mountains", 2010, "Zootaxa", "Smith"), false},
+		{"same title and year, first author only", crossrefItem("10.1000/w", "Ground beetles of the Altai Mountains", 2019, "Zootaxa", "Ivanova"), true},
+		{"same title, print year a year later", crossrefItem("10.1000/v", "Ground beetles of the Altai Mountains", 2020, "Euroasian Entomological Journal", "Ivanova", "Petrov"), true},
+		{"other title, same authors and year", crossrefItem("10.1000/z", "Spiders of Siberia", 2019, "Arthropoda Selecta", "Ivanova", "Petrov"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := crossrefStub(t, http.StatusOK, tt.item)
+			t.Setenv("REFMATCH_URL", srv.URL)
+			suggestion, err := newReferenceMatcher().Match(context.Background(), ref)
+			if err != nil {
+				t.Fatal(err)
+			}
+			score := scoreCandidate(ref, crossrefWorkOf(t, tt.item))
+			if got := suggestion != nil; got != tt.want {
+				t.Errorf("suggested = %v (score %.2f, threshold %.2f), want %v", got, score, refMatchMinScore, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseArticleReferencesStopsAfterFailures(t *testing.T) {
+	srv, requests := crossrefStub(t, http.StatusInternalServerError)
+	t.Setenv("REFMATCH_URL", srv.URL)
+	t.Setenv("CITATION_STYLE", "")
+
+	var refs []string
+	for i := 0; i < 6; i++ {
+		refs = append(refs, strings.Replace(matcherTestRef, "P.101-110", "P.1"+strings.Repeat("0", i)+"-2", 1))
+	}
+	articles := []Article{{doi: "10.15298/euroasentj.24.03.01", references: refs}}
+	parsed, err := parseArticleReferences(articles, eejJournal(t), newReferenceMatcher(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := requests.Load(); got != refMatchMaxFailures {
+		t.Errorf("%d requests, want matching to stop after %d failures", got, refMatchMaxFailures)
+	}
+	if len(parsed[0].parsed) != len(refs) {
+		t.Errorf("%d references parsed, want all %d", len(parsed[0].parsed), len(refs))
+	}
+	for i, s := range parsed[0].suggestions {
+		if s != nil {
+			t.Errorf("reference %d has a suggestion after failed requests", i+1)
+		}
+	}
+}
+
+func TestParseArticleReferencesSkipsReferencesWithDOI(t *testing.T) {
+	srv, requests := crossrefStub(t, http.StatusOK,
+		crossrefItem("10.15298/euroasentj.18.2.05", "Ground beetles of the Altai Mountains", 2019, "Euroasian Entomological Journal", "Ivanova", "Petrov"))
+	t.Setenv("REFMATCH_URL", srv.URL)
+	t.Setenv("CITATION_STYLE", "")
+
+	articles := []Article{{doi: "10.15298/euroasentj.24.03.01", references: []string{
+		strings.TrimSuffix(matcherTestRef, ".") + ". DOI: 10.15298/euroasentj.18.2.05",
+		matcherTestRef,
+	}}}
+	parsed, err := parseArticleReferences(articles, eejJournal(t), newReferenceMatcher(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("%d requests, want 1 for the reference without a DOI", got)
+	}
+	refs := parsed[0]
+	if refs.suggestions[0] != nil || refs.suggestions[1] == nil {
+		t.Errorf("suggestions = %v, want one for the second reference only", refs.suggestions)
+	}
+}
+
+func TestParseArticleReferencesBudget(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select { // slow API: answers after the budget, or when the lookup is cancelled
+		case <-time.After(5 * time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("REFMATCH_URL", srv.URL)
+	t.Setenv("CITATION_STYLE", "")
+	budget := refMatchBudget
+	t.Cleanup(func() { refMatchBudget = budget })
+	refMatchBudget = 100 * time.Millisecond
+
+	refs := []string{matcherTestRef, matcherTestRef, matcherTestRef, matcherTestRef}
+	articles := []Article{{doi: "10.15298/euroasentj.24.03.01", references: refs}, {doi: "10.15298/euroasentj.24.03.02", references: refs}}
+	start := time.Now()
+	parsed, err := parseArticleReferences(articles, eejJournal(t), newReferenceMatcher(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("lookups took %s with a budget of %s", elapsed, refMatchBudget)
+	}
+	for i, art := range parsed {
+		if len(art.parsed) != len(refs) {
+			t.Errorf("article %d: %d references parsed, want all %d", i+1, len(art.parsed), len(refs))
+		}
+	}
+}
+
+func TestScoreCandidateNeedsYearOrAuthor(t *testing.T) {
+	work := crossrefWorkOf(t, crossrefItem("10.1000/intro", "Introduction", 2019, "Zootaxa", "Smith"))
+	tests := []struct {
+		name string
+		ref  ParsedReference
+		want bool // scored above 0
+	}{
+		{"title alone", ParsedReference{Title: "Introduction", Year: "Not mentioned"}, false},
+		{"no title", ParsedReference{Year: "2019", AuthorList: []RefAuthor{{"Smith", "J."}}}, false},
+		{"title and year", ParsedReference{Title: "Introduction", Year: "2019"}, true},
+		{"title and author", ParsedReference{Title: "Introduction", Year: "Not mentioned", AuthorList: []RefAuthor{{"Smith", "J."}}}, true},
+	}
+	for _, tt := range tests {
+		if got := scoreCandidate(tt.ref, work); (got > 0) != tt.want {
+			t.Errorf("%s: score %.2f, want above 0: %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseArticleReferencesUnknownStyle(t *testing.T) {
+	t.Setenv("CITATION_STYLE", "harvard")
+	articles := []Article{{doi: "10.15298/euroasentj.24.03.01", references: []string{matcherTestRef}}}
+	_, err := parseArticleReferences(articles, eejJournal(t), nil, nil)
+	if err == nil || !strings.HasPrefix(err.Error(), `article 1: unknown citation style "harvard"`) {
+		t.Errorf("error %v, want the unknown style with the article number", err)
+	}
+}
+
+func crossrefWorkOf(t *testing.T, item map[string]any) crossrefWork {
+	t.Helper()
+	data, err := json.Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var work crossrefWork
+	if err := json.Unmarshal(data, &work); err != nil {
+		t.Fatal(err)
+	}
+	return work
+}
+
+// eejJournal is the registry entry of the Euroasian Entomological Journal
+func eejJournal(t *testing.T) JournalEntry {
+	t.Helper()
+	journal, ok := journalByCode("EEJ")
+	if !ok {
+		t.Fatal("EEJ is not in the journal registry")
+	}
+	return journal
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// runRefparse handles `server refparse <command>` (or `go run . refparse ...`); returns the exit code
+func runRefparse(args []string) int {
+	if len(args) == 0 || args[0] != "eval" {
+		fmt.Println("Usage: refparse eval [corpus.json]")
+		return 2
+	}
+	path := referenceCorpusPath
+	if len(args) > 1 {
+		path = args[1]
+	}
+	if err := evalReferenceCorpus(path); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// evalReferenceCorpus parses every case of the corpus and prints accuracy per reference type and per field
+func evalReferenceCorpus(path string) error {
+	cases, err := loadReferenceCorpus(path)
+	if err != nil {
+		return err
+	}
+
+	type tally struct{ total, passed int }
+	byType := map[string]*tally{}
+	fieldErrors := map[string]int{}
+	var failures []string
+	passed := 0
+
+	for i, c := range cases {
+		_, mismatched, err := c.check()
+		if err != nil {
+			return fmt.Errorf("case %d: %w", i+1, err)
+		}
+		t := byType[c.Type]
+		if t == nil {
+			t = &tally{}
+			byType[c.Type] = t
+		}
+		t.total++
+		if len(mismatched) == 0 {
+			t.passed++
+			passed++
+			if c.KnownFailure != "" {
+				failures = append(failures, fmt.Sprintf("   ✨ %d now parses correctly, remove known_failure", i+1))
+			}
+			continue
+		}
+		for _, field := range mismatched {
+			fieldErrors[field]++
+		}
+		note := ""
+		if c.KnownFailure != "" {
+			note = " (known: " + c.KnownFailure + ")"
+		}
+		failures = append(failures, fmt.Sprintf("   ❌ %d [%s] %s%s\n      %s", i+1, c.Type, strings.Join(mismatched, ", "), note, c.Ref))
+	}
+
+	types := make([]string, 0, len(byType))
+	for name := range byType {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+
+	fmt.Printf("📊 Reference corpus %s: %d references\n\n", path, len(cases))
+	fmt.Printf("   %-10s %6s %6s %9s\n", "type", "total", "passed", "accuracy")
+	for _, name := range types {
+		t := byType[name]
+		fmt.Printf("   %-10s %6d %6d %8.1f%%\n", name, t.total, t.passed, percent(t.passed, t.total))
+	}
+	fmt.Printf("   %-10s %6d %6d %8.1f%%\n\n", "all", len(cases), passed, percent(passed, len(cases)))
+
+	fmt.Println("   Field accuracy:")
+	for _, field := range referenceCaseFields {
+		fmt.Printf("   %-10s %8.1f%%\n", field, percent(len(cases)-fieldErrors[field], len(cases)))
+	}
+
+	if len(failures) > 0 {
+		fmt.Println()
+		fmt.Println(strings.Join(failures, "\n"))
+	}
+	return nil
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(n) / float64(total)
+}
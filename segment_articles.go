@@ -0,0 +1,384 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+/*
+ARTICLE SEGMENTATION
+
+An issue document is a flat sequence of articles. Each article consists of a
+header (citation line, authors/affiliations, DOI, abstract, keywords) followed
+by its reference list. Two segmenters split the document into articles:
+
+1. MARKERS (default when present)
+   Editors wrap every reference list in "<<<" and ">>>". Everything between the
+   previous ">>>" and the next "<<<" is the article header.
+
+2. STRUCTURE (fallback, or forced with SEGMENTATION=structure)
+   Article boundaries are derived from the text itself:
+   - key lines anchor the articles: "Abstract" headings, DOI lines of our
+     journals and @type markers or rubric headings ("Obituary") right above a
+     citation line, so items without an abstract are found too
+   - the article starts at the nearest citation line above its key lines
+     ("Authors. 2024. Title // Journal. Vol.24. No.3. P.123–130.") and the
+     markers or rubric headings above it, or at the DOI line if there is no
+     citation line
+   - the reference list starts after a "References" heading, or after the
+     last keywords paragraph ("Key words" / "Ключевые слова") when there is no
+     heading; an item without an abstract has no references otherwise
+   - the reference list ends where the next article starts
+
+When markers are present they are linted first (see lintMarkers): broken
+markers fail the conversion. Then both segmenters run and every disagreement is
+reported, so a missing or misplaced marker shows up as a warning instead of
+silently shifting references between articles.
+*/
+
+// articleSegment is one article of an issue: header text and raw reference list
+type articleSegment struct {
+	text       string
+	references string
+	startLine  int // 1-based line of the first header line
+	refLine    int // 1-based line where the reference list begins
+}
+
+// Segmentation modes (SEGMENTATION environment variable)
+const (
+	segmentationAuto      = "auto"
+	segmentationMarkers   = "markers"
+	segmentationStructure = "structure"
+)
+
+var (
+	artRefSepRegex     = regexp.MustCompile(`(?s)(.*?)<<<(.*?)>>>`)
+	segAbstractRegex   = regexp.MustCompile(`(?i)^\s*abstracts?\s*[.:]`)
+	segKeywordsRegex   = regexp.MustCompile(`(?i)^\s*(?:key\s*words|ключевые\s+слова)\s*[.:]`)
+	segReferencesRegex = regexp.MustCompile(`(?i)^\s*(references|literature cited|литература|список литературы)\s*[.:]?\s*$`)
+	segDOILineRegex    = regexp.MustCompile(`(?i)^\s*(?:doi\b|https?://(?:dx\.)?doi\.org/)`)
+	// citation line: year, "//" separator and a page range or single page
+	segCitationRegex = regexp.MustCompile(`\b(?:19|20)\d{2}[a-z]?\.\s.*//.*[\s.][PСS]\.\s*\d+(?:\s*[-–—]\s*\d+)?`)
+)
+
+// normalizeLineEndings converts Windows and old Mac line endings to "\n"
+func normalizeLineEndings(s string) string {
+	return strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(s)
+}
+
+// lineAt returns the 1-based line number of a byte offset in s
+func lineAt(s string, offset int) int {
+	if offset > len(s) {
+		offset = len(s)
+	}
+	return strings.Count(s[:offset], "\n") + 1
+}
+
+// firstTextOffset returns the offset of the first non-space character in s[start:end],
+// or start if the range is blank
+func firstTextOffset(s string, start, end int) int {
+	for i := start; i < end; i++ {
+		if !strings.ContainsRune(" \t\n", rune(s[i])) {
+			return i
+		}
+	}
+	return start
+}
+
+// segmentByMarkers splits the document on <<< >>> reference markers
+func segmentByMarkers(body string) []articleSegment {
+	var segments []articleSegment
+	for _, m := range artRefSepRegex.FindAllStringSubmatchIndex(body, -1) {
+		refStart := firstTextOffset(body, m[4], m[5])
+		// A "References" heading inside the markers is not part of the list itself
+		if lineEnd := strings.IndexByte(body[refStart:m[5]], '\n'); lineEnd != -1 &&
+			segReferencesRegex.MatchString(body[refStart:refStart+lineEnd]) {
+			refStart = firstTextOffset(body, refStart+lineEnd, m[5])
+		}
+		segments = append(segments, articleSegment{
+			text:       body[m[2]:m[3]],
+			references: body[m[4]:m[5]],
+			startLine:  lineAt(body, firstTextOffset(body, m[2], m[3])),
+			refLine:    lineAt(body, refStart),
+		})
+	}
+	return segments
+}
+
+// segmentByStructure splits the document using headings, DOI and citation lines.
+// Reference markers, if any, are ignored.
+func segmentByStructure(body string) []articleSegment {
+	body = strings.NewReplacer("<<<", "", ">>>", "").Replace(body)
+	lines := strings.Split(body, "\n")
+
+	// 1. Every key line belongs to the article whose citation line is the nearest above it
+	var headers []structureHeader
+	prevKey := -1
+	for i := range lines {
+		kind := segKeyLineKind(lines, i)
+		if kind == segKeyNone {
+			continue
+		}
+		start := -1
+		if kind == segKeyType {
+			start = extendArticleStart(lines, i)
+		} else if c := findCitationAbove(lines, prevKey+1, i); c != -1 {
+			start = extendArticleStart(lines, c)
+		}
+		prevKey = i
+
+		last := len(headers) - 1
+		switch {
+		case last >= 0 && start == headers[last].start:
+		case last >= 0 && start == -1 && headers[last].keys[kind] == -1:
+			// no citation line since the previous key line: still the same header
+		default:
+			if start == -1 {
+				// no citation line at all: the article starts at its DOI line or abstract
+				start = i
+			}
+			headers = append(headers, newStructureHeader(start))
+			last++
+		}
+		headers[last].keys[kind] = i
+	}
+
+	// 2. Reference lists run from the heading (or end of keywords) to the next article
+	segments := make([]articleSegment, 0, len(headers))
+	for k, h := range headers {
+		end := len(lines)
+		if k+1 < len(headers) {
+			end = headers[k+1].start
+		}
+
+		headerEnd, refStart := findReferencesStart(lines, h.lastKey(), end, h.keys[segKeyAbstract] != -1)
+
+		segments = append(segments, articleSegment{
+			text:       strings.Join(lines[h.start:headerEnd], "\n"),
+			references: strings.TrimSpace(strings.Join(lines[refStart:end], "\n")),
+			startLine:  h.start + 1,
+			refLine:    firstNonBlankLine(lines, refStart, end) + 1,
+		})
+	}
+	return segments
+}
+
+// Key lines of an article header for segmentByStructure
+const (
+	segKeyNone     = iota
+	segKeyAbstract // "Abstract" heading
+	segKeyDOI      // DOI line of one of our journals
+	segKeyType     // @type marker or rubric heading right above a citation line
+)
+
+// structureHeader is an article header found by segmentByStructure:
+// its first line and its key lines by kind (-1 when missing), all 0-based
+type structureHeader struct {
+	start int
+	keys  [4]int
+}
+
+func newStructureHeader(start int) structureHeader {
+	return structureHeader{start: start, keys: [4]int{-1, -1, -1, -1}}
+}
+
+// lastKey is the line the reference list is searched from: the abstract heading
+// if there is one, otherwise the last key line
+func (h structureHeader) lastKey() int {
+	if h.keys[segKeyAbstract] != -1 {
+		return h.keys[segKeyAbstract]
+	}
+	return max(h.start, h.keys[segKeyDOI], h.keys[segKeyType])
+}
+
+// segKeyLineKind tells whether lines[i] is a key line of an article header
+func segKeyLineKind(lines []string, i int) int {
+	line := lines[i]
+	switch {
+	case segAbstractRegex.MatchString(line):
+		return segKeyAbstract
+	case segDOILineRegex.MatchString(line):
+		// a DOI wrapped onto its own line in a reference list is not ours
+		if _, err := parseJournalDOI(normalizeDOI(line)); err == nil {
+			return segKeyDOI
+		}
+	case isArticleTypeLine(line):
+		for j := i + 1; j < len(lines); j++ {
+			if next := strings.TrimSpace(lines[j]); next != "" && !isArticleTypeLine(next) {
+				if segCitationRegex.MatchString(next) {
+					return segKeyType
+				}
+				break
+			}
+		}
+	}
+	return segKeyNone
+}
+
+// isArticleTypeLine reports an @type marker or a rubric heading line
+func isArticleTypeLine(line string) bool {
+	if articleTypeMarkerRegex.MatchString(line) {
+		return true
+	}
+	_, ok := articleTypeHeading(strings.TrimSpace(line))
+	return ok
+}
+
+// findCitationAbove returns the index of the nearest citation line in lines[lowerBound:anchor], or -1
+func findCitationAbove(lines []string, lowerBound, anchor int) int {
+	for i := anchor - 1; i >= lowerBound; i-- {
+		if segCitationRegex.MatchString(lines[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// extendArticleStart moves the start of an article up over the @type markers and
+// rubric headings right above its citation line
+func extendArticleStart(lines []string, start int) int {
+	for i := start - 1; i >= 0; i-- {
+		if isArticleTypeLine(lines[i]) {
+			start = i
+		} else if strings.TrimSpace(lines[i]) != "" {
+			break
+		}
+	}
+	return start
+}
+
+// findReferencesStart returns where the article header ends and where its
+// reference list begins, searching between the last key line and the next article.
+// Items without an abstract (obituaries, corrigenda) only have references under
+// a heading or after keywords.
+func findReferencesStart(lines []string, anchor, end int, hasAbstract bool) (headerEnd, refStart int) {
+	// A "References" heading is the clearest boundary
+	for i := anchor + 1; i < end; i++ {
+		if segReferencesRegex.MatchString(lines[i]) {
+			return i, i + 1
+		}
+	}
+
+	// Otherwise the references start after the last keywords paragraph
+	// (bilingual articles have "Key words" and then "Ключевые слова")
+	for i := end - 1; i > anchor; i-- {
+		if segKeywordsRegex.MatchString(lines[i]) {
+			return i + 1, i + 1
+		}
+	}
+
+	if !hasAbstract {
+		return end, end
+	}
+	// No keywords either: assume the abstract is one paragraph
+	return min(anchor+1, end), min(anchor+1, end)
+}
+
+func firstNonBlankLine(lines []string, from, to int) int {
+	for i := from; i < to; i++ {
+		if strings.TrimSpace(lines[i]) != "" {
+			return i
+		}
+	}
+	return from
+}
+
+// compareSegmentations reports where marker-based and structural segmentation disagree.
+// Returned messages are keyed by 1-based marker article number (0 = whole document).
+func compareSegmentations(byMarkers, byStructure []articleSegment) map[int][]string {
+	diffs := make(map[int][]string)
+	if len(byMarkers) != len(byStructure) {
+		diffs[0] = append(diffs[0], fmt.Sprintf(
+			"markers give %d articles, document structure suggests %d", len(byMarkers), len(byStructure)))
+	}
+
+	structStarts := make(map[int]int, len(byStructure))
+	for i, seg := range byStructure {
+		structStarts[seg.startLine] = i
+	}
+
+	for i, seg := range byMarkers {
+		j, ok := structStarts[seg.startLine]
+		if !ok {
+			diffs[i+1] = append(diffs[i+1], fmt.Sprintf(
+				"article starts at line %d (%q), but no article start was detected there from structure",
+				seg.startLine, lineSnippet(seg.text)))
+			continue
+		}
+		noRefs := strings.TrimSpace(seg.references) == "" && byStructure[j].references == ""
+		if !noRefs && byStructure[j].refLine != seg.refLine {
+			diffs[i+1] = append(diffs[i+1], fmt.Sprintf(
+				"references start at line %d by markers but at line %d by structure",
+				seg.refLine, byStructure[j].refLine))
+		}
+	}
+	return diffs
+}
+
+// lineSnippet returns the first non-blank line of s, shortened for messages
+func lineSnippet(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			if len([]rune(line)) > 60 {
+				return string([]rune(line)[:60]) + "…"
+			}
+			return line
+		}
+	}
+	return ""
+}
+
+// segmentArticles picks the segmenter according to SEGMENTATION (auto|markers|structure)
+// and, when markers are used, cross-checks them against the document structure
+func segmentArticles(body string, clog *ConversionLog) ([]articleSegment, error) {
+	mode := os.Getenv("SEGMENTATION")
+	if mode == "" {
+		mode = segmentationAuto
+	}
+
+	hasMarkers := strings.Contains(body, "<<<") || strings.Contains(body, ">>>")
+
+	switch mode {
+	case segmentationStructure:
+		clog.Println("Segmentation: document structure (markers ignored)")
+		return segmentByStructure(body), nil
+	case segmentationMarkers:
+		if !hasMarkers {
+			return nil, fmt.Errorf("SEGMENTATION=markers but the document has no <<< >>> reference markers")
+		}
+	case segmentationAuto:
+		if !hasMarkers {
+			clog.Println("Segmentation: no <<< >>> markers found, using document structure")
+			return segmentByStructure(body), nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown SEGMENTATION mode %q (expected auto, markers or structure)", mode)
+	}
+
+	clog.Println("Segmentation: <<< >>> markers")
+	var markerErrors []markerIssue
+	for _, issue := range lintMarkers(body) {
+		if issue.severity == markerWarning {
+			clog.Printf("⚠️  MARKERS: %s\n", issue)
+			continue
+		}
+		clog.Printf("❌ MARKERS: %s\n", issue)
+		markerErrors = append(markerErrors, issue)
+	}
+	if len(markerErrors) > 0 {
+		return nil, markerLintError(markerErrors)
+	}
+	byMarkers := segmentByMarkers(body)
+	diffs := compareSegmentations(byMarkers, segmentByStructure(body))
+	for _, msg := range diffs[0] {
+		clog.Printf("⚠️  SEGMENTATION: %s\n", msg)
+	}
+	for i := range byMarkers {
+		for _, msg := range diffs[i+1] {
+			clog.printWarning(i+1, "SEGMENTATION", msg)
+		}
+	}
+	return byMarkers, nil
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// segmentWant is what a test expects of one article segment
+type segmentWant struct {
+	startLine int
+	refLine   int
+	firstLine string // first line of the header text
+	firstRef  string // first line of the reference list, "" for none
+}
+
+func checkSegments(t *testing.T, got []articleSegment, want []segmentWant) {
+	t.Helper()
+	if len(got) != len(want) {
+		for _, seg := range got {
+			t.Logf("segment at line %d: %q", seg.startLine, lineSnippet(seg.text))
+		}
+		t.Fatalf("got %d segments, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		seg := got[i]
+		if seg.startLine != w.startLine {
+			t.Errorf("segment %d: startLine = %d, want %d", i+1, seg.startLine, w.startLine)
+		}
+		if w.firstRef != "" && seg.refLine != w.refLine {
+			t.Errorf("segment %d: refLine = %d, want %d", i+1, seg.refLine, w.refLine)
+		}
+		if first := firstLineOf(seg.text); first != w.firstLine {
+			t.Errorf("segment %d: header starts with %q, want %q", i+1, first, w.firstLine)
+		}
+		if first := firstLineOf(seg.references); first != w.firstRef {
+			t.Errorf("segment %d: references start with %q, want %q", i+1, first, w.firstRef)
+		}
+	}
+}
+
+// firstLineOf returns the first non-blank line of s, unlike lineSnippet not shortened
+func firstLineOf(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+const (
+	segCitation1 = "Ivanova A.B. 2025. A new Amara // Euroasian Entomological Journal. Vol.24. No.3. P.1–8."
+	segCitation2 = "Petrov C.D. 2025. Ground beetles // Euroasian Entomological Journal. Vol.24. No.3. P.9–15."
+	segCitation3 = "Sidorov E.F. 2025. Alexander Ivanov // Euroasian Entomological Journal. Vol.24. No.3. P.16."
+)
+
+func TestSegmentByStructure(t *testing.T) {
+	tests := []struct {
+		name string
+		body []string
+		want []segmentWant
+	}{
+		{
+			name: "citation line followed by a title",
+			body: []string{
+				segCitation1,
+				"A new species of Amara from Altai",
+				"A.B. Ivanova",
+				"Abstract. A new species is described.",
+				"Key words: Carabidae, Amara.",
+				"References",
+				"Jeannel R. 1941. Faune de France.",
+				segCitation2,
+				"Ground beetles of the Kuznetsk Alatau",
+				"C.D. Petrov",
+				"Abstract. The fauna is listed.",
+				"Key words: Carabidae.",
+				"References",
+				"Kryzhanovskij O.L. 1983. Fauna SSSR.",
+			},
+			want: []segmentWant{
+				{1, 7, segCitation1, "Jeannel R. 1941. Faune de France."},
+				{8, 14, segCitation2, "Kryzhanovskij O.L. 1983. Fauna SSSR."},
+			},
+		},
+		{
+			name: "references after the last keywords paragraph",
+			body: []string{
+				segCitation1,
+				"A new species of Amara from Altai",
+				"Abstract. A new species is described.",
+				"Key words: Carabidae, Amara.",
+				"Ключевые слова: Carabidae, Amara.",
+				"Jeannel R. 1941. Faune de France.",
+			},
+			want: []segmentWant{
+				{1, 6, segCitation1, "Jeannel R. 1941. Faune de France."},
+			},
+		},
+		{
+			name: "rubric heading above the citation line",
+			body: []string{
+				segCitation2,
+				"Ground beetles of the Kuznetsk Alatau",
+				"Abstract. The fauna is listed.",
+				"Key words: Carabidae.",
+				"References",
+				"Kryzhanovskij O.L. 1983. Fauna SSSR.",
+				"",
+				"Obituary",
+				segCitation3,
+				"Alexander Ivanov (1950–2024)",
+				"He described many species.",
+			},
+			want: []segmentWant{
+				{1, 6, segCitation2, "Kryzhanovskij O.L. 1983. Fauna SSSR."},
+				{8, 0, "Obituary", ""},
+			},
+		},
+		{
+			name: "@type marker and a rubric heading",
+			body: []string{
+				"@type: editorial",
+				"From the editors",
+				segCitation3,
+				"On the new volume",
+				"References",
+				"Ivanov A. 2024. Fauna.",
+			},
+			want: []segmentWant{
+				{1, 6, "@type: editorial", "Ivanov A. 2024. Fauna."},
+			},
+		},
+		{
+			name: "DOI line without a citation line",
+			body: []string{
+				"https://doi.org/10.15298/euroasentj.24.3.01",
+				"A new species of Amara from Altai",
+				"Abstract. A new species is described.",
+				"Key words: Carabidae.",
+				"References",
+				"Jeannel R. 1941. Faune de France.",
+				"https://doi.org/10.1007/s00000-000-0000-0",
+			},
+			want: []segmentWant{
+				{1, 6, "https://doi.org/10.15298/euroasentj.24.3.01", "Jeannel R. 1941. Faune de France."},
+			},
+		},
+		{
+			name: "markers are ignored",
+			body: []string{
+				segCitation1,
+				"Abstract. A new species is described.",
+				"Key words: Carabidae.",
+				"<<<References",
+				"Jeannel R. 1941. Faune de France.>>>",
+				segCitation2,
+				"Abstract. The fauna is listed.",
+				"Key words: Carabidae.",
+				"<<<Kryzhanovskij O.L. 1983. Fauna SSSR.>>>",
+			},
+			want: []segmentWant{
+				{1, 5, segCitation1, "Jeannel R. 1941. Faune de France."},
+				{6, 9, segCitation2, "Kryzhanovskij O.L. 1983. Fauna SSSR."},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			checkSegments(t, segmentByStructure(strings.Join(tc.body, "\n")), tc.want)
+		})
+	}
+}
+
+func TestSegmentArticles(t *testing.T) {
+	marked := strings.Join([]string{
+		segCitation1,
+		"Abstract. A new species is described.",
+		"Key words: Carabidae.",
+		"<<<Jeannel R. 1941. Faune de France.>>>",
+		segCitation2,
+		"Abstract. The fauna is listed.",
+		"Key words: Carabidae.",
+		"<<<Kryzhanovskij O.L. 1983. Fauna SSSR.>>>",
+	}, "\n")
+	unmarked := strings.NewReplacer("<<<", "", ">>>", "").Replace(marked)
+	// the first reference list closes one line late: the second citation line
+	// ends up among the references
+	misplaced := strings.Join([]string{
+		segCitation1,
+		"Abstract. A new species is described.",
+		"Key words: Carabidae.",
+		"<<<Jeannel R. 1941. Faune de France.",
+		segCitation2 + ">>>",
+		"Abstract. The fauna is listed.",
+		"Key words: Carabidae.",
+		"<<<Kryzhanovskij O.L. 1983. Fauna SSSR.>>>",
+	}, "\n")
+	twoArticles := []segmentWant{
+		{1, 4, segCitation1, "Jeannel R. 1941. Faune de France."},
+		{5, 8, segCitation2, "Kryzhanovskij O.L. 1983. Fauna SSSR."},
+	}
+
+	tests := []struct {
+		name     string
+		mode     string
+		body     string
+		want     []segmentWant
+		warnings []string // fragments of the expected warnings
+		err      string
+	}{
+		{name: "auto uses markers", mode: "", body: marked, want: twoArticles},
+		{name: "auto falls back to structure", mode: "auto", body: unmarked, want: twoArticles},
+		{name: "structure ignores markers", mode: "structure", body: misplaced, want: twoArticles},
+		{
+			name: "misplaced marker is reported",
+			mode: "markers",
+			body: misplaced,
+			want: []segmentWant{
+				{1, 4, segCitation1, "Jeannel R. 1941. Faune de France."},
+				{6, 8, "Abstract. The fauna is listed.", "Kryzhanovskij O.L. 1983. Fauna SSSR."},
+			},
+			warnings: []string{
+				"article starts at line 6",
+			},
+		},
+		{name: "markers required", mode: "markers", body: unmarked, err: "has no <<< >>> reference markers"},
+		{name: "broken markers", mode: "auto", body: marked + "\n<<<Orphan.", err: "never closed"},
+		{name: "unknown mode", mode: "chapters", body: marked, err: `unknown SEGMENTATION mode "chapters"`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("SEGMENTATION", tc.mode)
+			var warnings []string
+			clog := &ConversionLog{onEntry: func(e LogEntry) {
+				if e.Level == logWarning {
+					warnings = append(warnings, e.Message)
+				}
+			}}
+
+			got, err := segmentArticles(tc.body, clog)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("err = %v, want one containing %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkSegments(t, got, tc.want)
+
+			for _, w := range tc.warnings {
+				found := false
+				for _, got := range warnings {
+					found = found || strings.Contains(got, w)
+				}
+				if !found {
+					t.Errorf("no warning containing %q in %q", w, warnings)
+				}
+			}
+			if tc.warnings == nil && len(warnings) > 0 {
+				t.Errorf("unexpected warnings %q", warnings)
+			}
+		})
+	}
+}
@@ -2,16 +2,26 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// jobs is the conversion queue behind the /api/jobs endpoints
+var jobs *JobQueue
+
 func main() {
+	// Subcommands (e.g. "refparse eval") run instead of the server
+	if code, ok := runCommand(os.Args[1:]); ok {
+		os.Exit(code)
+	}
+
 	// Create temp directory for uploads
 	if err := os.MkdirAll("./temp", 0755); err != nil {
 		log.Fatal("Failed to create temp directory:", err)
@@ -28,9 +38,10 @@ func main() {
 	// Increase max upload size to 50MB
 	router.MaxMultipartMemory = 50 << 20 // 50 MB
 
-	// API endpoints
-	router.GET("/health", healthCheck)
-	router.POST("/api/convert", handleConvert)
+	// Conversion jobs run on a worker pool (see jobs.go)
+	jobs = newJobQueue()
+
+	apiRoutes(router)
 
 	// Serve frontend static files
 	router.StaticFile("/", "./frontend/index.html")
@@ -52,6 +63,34 @@ func main() {
 	}
 }
 
+// apiRoutes registers the API endpoints
+func apiRoutes(router *gin.Engine) {
+	router.GET("/health", healthCheck)
+	router.POST("/api/convert", handleConvert)
+	router.POST("/api/jobs", handleCreateJob)
+	router.GET("/api/jobs/:id", handleJobStatus)
+	router.GET("/api/jobs/:id/download", handleJobDownload)
+	router.GET("/api/jobs/:id/events", handleJobEvents)
+}
+
+// runCommand dispatches command-line subcommands; ok is false when the server should start
+func runCommand(args []string) (code int, ok bool) {
+	if len(args) == 0 {
+		return 0, false
+	}
+	switch args[0] {
+	case "refparse":
+		return runRefparse(args[1:]), true
+	case "citations":
+		return runCitations(args[1:]), true
+	case "journal-report":
+		return runJournalReport(args[1:]), true
+	case "crossref":
+		return runCrossref(args[1:]), true
+	}
+	return 0, false
+}
+
 // healthCheck returns server status
 func healthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
@@ -62,26 +101,27 @@ func healthCheck(c *gin.Context) {
 	})
 }
 
-// handleConvert processes uploaded DOC/DOCX file and returns Excel
-func handleConvert(c *gin.Context) {
+// receiveUpload validates the uploaded DOC/DOCX file and saves it to ./temp
+// On failure the error response is already written and ok is false
+func receiveUpload(c *gin.Context) (filename, inputPath, outputPath string, ok bool) {
 	// 1. Get uploaded file
 	file, err := c.FormFile("document")
 	if err != nil {
 		log.Printf("❌ Upload error: %v\n", err)
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "No file uploaded. Please select a .doc or .docx file",
+			"error": "No file uploaded. Please select a .doc, .docx or .odt file",
 		})
-		return
+		return "", "", "", false
 	}
 
 	// 2. Validate file extension
 	ext := filepath.Ext(file.Filename)
-	if ext != ".doc" && ext != ".docx" {
+	if ext != ".doc" && ext != ".docx" && ext != ".odt" {
 		log.Printf("❌ Invalid file type: %s\n", file.Filename)
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": fmt.Sprintf("Invalid file type '%s'. Only .doc and .docx files are allowed", ext),
+			"error": fmt.Sprintf("Invalid file type '%s'. Only .doc, .docx and .odt files are allowed", ext),
 		})
-		return
+		return "", "", "", false
 	}
 
 	// 3. Validate file size (50MB max)
@@ -90,7 +130,7 @@ func handleConvert(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": fmt.Sprintf("File too large (%.2f MB). Maximum allowed: 50 MB", float64(file.Size)/(1024*1024)),
 		})
-		return
+		return "", "", "", false
 	}
 
 	// 4. Create unique filenames with timestamp
@@ -98,8 +138,8 @@ func handleConvert(c *gin.Context) {
 	inputFilename := fmt.Sprintf("%d_%s", timestamp, file.Filename)
 	outputFilename := fmt.Sprintf("%d_output.xlsx", timestamp)
 
-	inputPath := filepath.Join("./temp", inputFilename)
-	outputPath := filepath.Join("./temp", outputFilename)
+	inputPath = filepath.Join("./temp", inputFilename)
+	outputPath = filepath.Join("./temp", outputFilename)
 
 	// 5. Save uploaded file
 	if err := c.SaveUploadedFile(file, inputPath); err != nil {
@@ -107,14 +147,24 @@ func handleConvert(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to save uploaded file",
 		})
-		return
+		return "", "", "", false
 	}
 
 	log.Printf("📥 Uploaded: %s (%.2f MB)\n", file.Filename, float64(file.Size)/(1024*1024))
+	return file.Filename, inputPath, outputPath, true
+}
+
+// handleConvert processes uploaded DOC/DOCX file and returns Excel
+// The conversion runs within the request; the frontend uses the job API
+func handleConvert(c *gin.Context) {
+	filename, inputPath, outputPath, ok := receiveUpload(c)
+	if !ok {
+		return
+	}
 
-	// 6. Process document (convert to Excel)
-	log.Printf("⚙️  Processing: %s\n", file.Filename)
-	err = processDocument(inputPath, outputPath)
+	// Process document (convert to Excel)
+	log.Printf("⚙️  Processing: %s\n", filename)
+	err := processDocument(inputPath, outputPath, nil)
 	if err != nil {
 		log.Printf("❌ Processing failed: %v\n", err)
 		// Clean up input file immediately on error
@@ -132,17 +182,119 @@ func handleConvert(c *gin.Context) {
 		return
 	}
 
-	log.Printf("✅ Processed successfully: %s → %s\n", file.Filename, outputFilename)
+	log.Printf("✅ Processed successfully: %s → %s\n", filename, filepath.Base(outputPath))
 
-	baseFilename := file.Filename[:len(file.Filename)-len(ext)]
-	downloadFilename := fmt.Sprintf("%s.xlsx", baseFilename)
+	sendWorkbook(c, outputPath, workbookName(filename))
 
+	go cleanupFiles(inputPath, outputPath, filename)
+}
+
+// handleCreateJob queues the uploaded document for conversion and returns the job
+func handleCreateJob(c *gin.Context) {
+	filename, inputPath, outputPath, ok := receiveUpload(c)
+	if !ok {
+		return
+	}
+
+	job, err := jobs.Submit(filename, inputPath, outputPath)
+	if err != nil {
+		log.Printf("❌ Job not queued: %v\n", err)
+		if removeErr := os.Remove(inputPath); removeErr != nil {
+			log.Printf("⚠️  Failed to delete input file: %v\n", removeErr)
+		}
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": fmt.Sprintf("Conversion not started: %v", err),
+		})
+		return
+	}
+
+	log.Printf("🧾 Job %s queued: %s\n", job.ID, filename)
+	c.JSON(http.StatusAccepted, job)
+}
+
+// handleJobStatus reports the status, stage and progress of a job
+func handleJobStatus(c *gin.Context) {
+	job, ok := jobs.Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Job not found or expired",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// handleJobDownload returns the Excel file of a finished job
+func handleJobDownload(c *gin.Context) {
+	job, ok := jobs.Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Job not found or expired",
+		})
+		return
+	}
+	if job.Status != JobDone {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":  fmt.Sprintf("Job is %s, there is nothing to download", job.Status),
+			"status": job.Status,
+		})
+		return
+	}
+	sendWorkbook(c, job.outputPath, job.DownloadName())
+}
+
+// workbookName is the uploaded file's name with the .xlsx extension
+func workbookName(filename string) string {
+	return strings.TrimSuffix(filename, filepath.Ext(filename)) + ".xlsx"
+}
+
+// handleJobEvents streams the conversion log of a job as server-sent events:
+// "log" for every entry, from the first one, and "end" with the finished job
+func handleJobEvents(c *gin.Context) {
+	id := c.Param("id")
+	if _, ok := jobs.Get(id); !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Job not found or expired",
+		})
+		return
+	}
+
+	// nginx would otherwise buffer the stream until the job is done
+	c.Header("X-Accel-Buffering", "no")
+	next := 0
+	c.Stream(func(w io.Writer) bool {
+		entries, finished, changed, ok := jobs.Log(id, next)
+		if !ok {
+			return false
+		}
+		if len(entries) == 0 && !finished {
+			select {
+			case <-changed:
+				return true
+			case <-c.Request.Context().Done():
+				return false
+			}
+		}
+		for _, entry := range entries {
+			c.SSEvent("log", entry)
+		}
+		next += len(entries)
+		if finished {
+			if job, ok := jobs.Get(id); ok {
+				c.SSEvent("end", job)
+			}
+			return false
+		}
+		return true
+	})
+}
+
+// sendWorkbook returns an Excel file as an attachment
+func sendWorkbook(c *gin.Context, path, downloadFilename string) {
 	c.Header("Content-Description", "File Transfer")
 	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", downloadFilename))
 	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
-	c.File(outputPath)
-
-	go cleanupFiles(inputPath, outputPath, file.Filename)
+	c.File(path)
 }
 
 // cleanupFiles removes temporary files after a delay
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -38,6 +39,9 @@ type JournalState struct {
 	CurrentCounter   int              `yaml:"current_counter"`
 	ProcessedIssues  []ProcessedIssue `yaml:"processed_issues"`
 	MaxHistory       int              `yaml:"max_history"`
+	// Journal settings, overriding the journal registry (see configuredJournal)
+	CitationStyle    string           `yaml:"citation_style,omitempty"`
+	PageFormat       *PageFormat      `yaml:"page_format,omitempty"`
 	stateFilePath    string           // Internal field, not serialized
 }
 
@@ -57,12 +61,29 @@ type StateManager struct {
 }
 
 // NewStateManager creates a new state manager
+// State files are kept in STATE_DIR, "state" by default
 func NewStateManager() *StateManager {
+	stateDir := os.Getenv("STATE_DIR")
+	if stateDir == "" {
+		stateDir = "state"
+	}
 	return &StateManager{
-		stateDir: "state",
+		stateDir: stateDir,
 	}
 }
 
+// stateLocks holds a mutex per state file, shared by all StateManagers
+var stateLocks sync.Map
+
+// Lock serializes conversions of a journal until unlock is called:
+// numbers are allocated from the state file and recorded only after the workbook is saved
+func (sm *StateManager) Lock(journalCode string) (unlock func()) {
+	stateFile := filepath.Join(sm.stateDir, fmt.Sprintf("%s_state.yaml", journalCode))
+	mu, _ := stateLocks.LoadOrStore(stateFile, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
+
 // LoadState loads the state file for a journal
 func (sm *StateManager) LoadState(journalCode string) (*JournalState, error) {
 	stateFile := filepath.Join(sm.stateDir, fmt.Sprintf("%s_state.yaml", journalCode))
@@ -234,31 +255,23 @@ func (sm *StateManager) RemoveIssue(state *JournalState, volume, issue string) e
 }
 
 // HandleDuplicateIssue handles when a duplicate issue is detected
-// Default behavior: reprocess with existing numbers
-func (sm *StateManager) HandleDuplicateIssue(existingIssue ProcessedIssue, journalCode string) DuplicateAction {
-	fmt.Println("\n⚠️  Issue already processed!")
-	fmt.Printf("Journal: %s, Volume: %s, Issue: %s\n", journalCode, existingIssue.Volume, existingIssue.Issue)
-	fmt.Printf("Previously processed on: %s\n", existingIssue.ProcessedDate.Format("2006-01-02 15:04:05"))
-	fmt.Printf("Articles: %d (numbers %d-%d)\n", existingIssue.ArticleCount, existingIssue.StartNumber, existingIssue.EndNumber)
-	fmt.Println("→ Reprocessing with existing numbers (default behavior)")
-	fmt.Println()
+// Default behavior: reprocess with existing numbers. Output goes to clog.
+func (sm *StateManager) HandleDuplicateIssue(existingIssue ProcessedIssue, journalCode string, clog *ConversionLog) DuplicateAction {
+	clog.Println("\n⚠️  Issue already processed!")
+	clog.Printf("Journal: %s, Volume: %s, Issue: %s\n", journalCode, existingIssue.Volume, existingIssue.Issue)
+	clog.Printf("Previously processed on: %s\n", existingIssue.ProcessedDate.Format("2006-01-02 15:04:05"))
+	clog.Printf("Articles: %d (numbers %d-%d)\n", existingIssue.ArticleCount, existingIssue.StartNumber, existingIssue.EndNumber)
+	clog.Println("→ Reprocessing with existing numbers (default behavior)")
+	clog.Println()
 
 	return ReprocessSameNumbers
 }
 
 // ExtractJournalCodeFromDOI extracts the journal code from a DOI
 func ExtractJournalCodeFromDOI(doi string) (string, error) {
-	// Map DOI prefixes to journal codes
-	doiToJournal := map[string]string{
-		"euroasentj":  "EEJ",
-		"rusentj":     "REJ",
-		"invertzool":  "IZ",
-		"arthsel":     "AS",
-	}
-
-	for prefix, code := range doiToJournal {
-		if strings.Contains(doi, prefix) {
-			return code, nil
+	for _, journal := range journalRegistry {
+		if strings.Contains(doi, journal.DOICode) {
+			return journal.Code, nil
 		}
 	}
 
@@ -14,12 +14,34 @@ type Reference struct {
 }
 
 type Article struct {
+	articleType  ArticleType
 	title        string
-	abstract     string
-	pages        string
-	keywords     string
+	abstractEn   string
+	pages        PageRange
+	keywordsEn   []string
 	authors      string
 	affiliations string
 	references   []string
 	doi          string
+	citation     articleCitation // parsed citation line (volume, issue, year)
+	emails       []string        // per author, "" when unknown
+	orcids       []string        // per author, https://orcid.org/ form
+	// per author, "Canonical Name (registry ID)" or "" when unmatched
+	affiliationOrgs []string
+	countries       []string // per author, ISO country code of the affiliation
+	// Russian-language block ("Резюме", "Ключевые слова" and the Russian citation line)
+	titleRu    string
+	authorsRu  string
+	abstractRu string
+	keywordsRu []string
+	// corrections: the article this item corrects
+	updates ArticleUpdate
+}
+
+// formattedPages renders the article's pages for the articles sheet
+func (a Article) formattedPages(pf PageFormat) string {
+	if a.pages == (PageRange{}) {
+		return ""
+	}
+	return a.pages.Format(pf)
 }